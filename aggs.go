@@ -0,0 +1,404 @@
+package queryBuilder
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// Aggregation is implemented by every aggregation node. It is deliberately
+// distinct from Query, so aggregations and queries cannot be mixed up.
+type Aggregation interface {
+	AggregationName() string
+	MarshalAggregation(dc DataSource) (any, error)
+}
+
+// renderAgg marshals the argument arg of call, attributing any error to it.
+func renderAgg(a Aggregation, dc DataSource, call, arg string) (any, error) {
+	if a == nil {
+		return nil, &BuildError{call, arg, fmt.Errorf("%w: nil aggregation", ErrWrongNodeKind)}
+	}
+	if a.AggregationName() == "" {
+		return nil, &BuildError{call, arg, ErrEmptyName}
+	}
+	v, err := a.MarshalAggregation(dc)
+	if err != nil {
+		return nil, &BuildError{call, arg, err}
+	}
+	return v, nil
+}
+
+// renderAggs marshals sibling aggregations passed as values to call.
+func renderAggs(values []Aggregation, dc DataSource, call string) (map[string]any, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+
+	var errs []error
+	aggs := make(map[string]any, len(values))
+	for i, a := range values {
+		arg := fmt.Sprintf("values[%d]", i)
+		agg, err := renderAgg(a, dc, call, arg)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		name := a.AggregationName()
+		if _, dup := aggs[name]; dup {
+			errs = append(errs, &BuildError{call, arg, fmt.Errorf("%w: %q", ErrDuplicateName, name)})
+		}
+		aggs[name] = agg
+	}
+	return aggs, errors.Join(errs...)
+}
+
+// withSubAggs adds the rendered sub-aggregations to an aggregation body.
+func withSubAggs(body map[string]any, subAggs []Aggregation, dc DataSource, call string) (map[string]any, error) {
+	var errs []error
+	for i, a := range subAggs {
+		if _, ok := a.(*GlobalAggregation); ok {
+			errs = append(errs, &BuildError{call, fmt.Sprintf("values[%d]", i), fmt.Errorf("%w: global aggregations are only valid at the top level", ErrWrongNodeKind)})
+		}
+	}
+	aggs, err := renderAggs(subAggs, dc, call)
+	if err := errors.Join(append(errs, err)...); err != nil {
+		return nil, err
+	}
+	if aggs != nil {
+		body["aggs"] = aggs
+	}
+	return body, nil
+}
+
+// parentAggregation is implemented by aggregations that accept sub-aggregations.
+type parentAggregation interface {
+	subAggregations() []Aggregation
+}
+
+// walkAggs calls fn for every aggregation of the tree, parents first.
+func walkAggs(values []Aggregation, fn func(Aggregation)) {
+	for _, a := range values {
+		if a == nil {
+			continue
+		}
+		fn(a)
+		if p, ok := a.(parentAggregation); ok {
+			walkAggs(p.subAggregations(), fn)
+		}
+	}
+}
+
+func generateAggs(values []Aggregation, dc DataSource) (map[string]any, error) {
+	aggs, err := renderAggs(values, dc, "Builder.Aggs")
+	return aggs, errors.Join(err, checkBucketsPaths(values))
+}
+
+// Aggs adds top-level aggregations to those already added. Names must be
+// unique across calls; Build reports duplicates.
+func (b *Builder) Aggs(values ...Aggregation) *Builder {
+	b.aggs = append(b.aggs, values...)
+	return b
+}
+
+// RemoveAgg drops the top-level aggregation called name, if any.
+func (b *Builder) RemoveAgg(name string) *Builder {
+	b.aggs = slices.DeleteFunc(b.aggs, func(a Aggregation) bool {
+		return a != nil && a.AggregationName() == name
+	})
+	return b
+}
+
+func (b *Builder) ClearAggs() *Builder {
+	b.aggs = nil
+	return b
+}
+
+type TermsAggregation struct {
+	Params  AggregateParams
+	subAggs []Aggregation
+}
+
+type AggregateParams struct {
+	Name                  string
+	FieldName             string
+	Order                 map[string]string
+	OrderBy               []TermsOrder // several sort keys, in priority order; replaces Order
+	Size                  int
+	ShardSize             int
+	MinDocCount           *int // nil keeps the server default of 1
+	Missing               any
+	Include               *TermsFilter
+	Exclude               *TermsFilter
+	ExecutionHint         string // map or global_ordinals
+	ShowTermDocCountError bool
+}
+
+type TermsOrder struct {
+	Key   string // _count, _key or a sub-aggregation path
+	Order string // asc or desc
+}
+
+// TermsFilter selects terms by Regex, by an exact list of Values, or, for
+// include only, by hash partition.
+type TermsFilter struct {
+	Regex         string
+	Values        []any
+	Partition     int
+	NumPartitions int
+}
+
+func (f *TermsFilter) generate(call, arg string, partitioned bool) (any, error) {
+	set := 0
+	for _, ok := range []bool{f.Regex != "", len(f.Values) > 0, f.NumPartitions != 0} {
+		if ok {
+			set++
+		}
+	}
+	switch {
+	case set != 1:
+		return nil, &BuildError{call, arg, fmt.Errorf("%w: exactly one of Regex, Values or NumPartitions must be set", ErrInvalidValue)}
+	case f.Regex != "":
+		return f.Regex, nil
+	case len(f.Values) > 0:
+		return f.Values, nil
+	case !partitioned:
+		return nil, &BuildError{call, arg, fmt.Errorf("%w: partitions only apply to include", ErrInvalidValue)}
+	case f.NumPartitions < 0 || f.Partition < 0 || f.Partition >= f.NumPartitions:
+		return nil, &BuildError{call, arg, fmt.Errorf("%w: partition %d of %d", ErrInvalidValue, f.Partition, f.NumPartitions)}
+	}
+	return map[string]int{"partition": f.Partition, "num_partitions": f.NumPartitions}, nil
+}
+
+func (m *TermsAggregation) AggregationName() string {
+	return m.Params.Name
+}
+
+func (m *TermsAggregation) MarshalAggregation(dc DataSource) (any, error) {
+	p := m.Params
+	if p.FieldName == "" {
+		return nil, &BuildError{"TermsAgg", "params.FieldName", ErrEmptyField}
+	}
+	if p.Size < 0 {
+		return nil, &BuildError{"TermsAgg", "params.Size", ErrNegative}
+	}
+	if p.ShardSize < 0 {
+		return nil, &BuildError{"TermsAgg", "params.ShardSize", ErrNegative}
+	}
+	if p.MinDocCount != nil && *p.MinDocCount < 0 {
+		return nil, &BuildError{"TermsAgg", "params.MinDocCount", ErrNegative}
+	}
+
+	terms := map[string]any{"field": p.FieldName}
+	setIf(terms, "size", p.Size, p.Size != 0)
+	setIf(terms, "shard_size", p.ShardSize, p.ShardSize != 0)
+	if p.MinDocCount != nil {
+		terms["min_doc_count"] = *p.MinDocCount
+	}
+	setIf(terms, "missing", p.Missing, p.Missing != nil)
+	setIf(terms, "execution_hint", p.ExecutionHint, p.ExecutionHint != "")
+	setIf(terms, "show_term_doc_count_error", true, p.ShowTermDocCountError)
+
+	order, err := termsOrder(dc, "TermsAgg", "params.OrderBy", p.Order, p.OrderBy)
+	if err != nil {
+		return nil, err
+	}
+	setIf(terms, "order", order, order != nil)
+
+	if p.Include != nil {
+		include, err := p.Include.generate("TermsAgg", "params.Include", true)
+		if err != nil {
+			return nil, err
+		}
+		terms["include"] = include
+	}
+	if p.Exclude != nil {
+		exclude, err := p.Exclude.generate("TermsAgg", "params.Exclude", false)
+		if err != nil {
+			return nil, err
+		}
+		terms["exclude"] = exclude
+	}
+
+	return withSubAggs(map[string]any{"terms": terms}, m.subAggs, dc, "TermsAggregation.SubAggs")
+}
+
+// termsOrder renders Order as an object, or OrderBy as a list of single-key
+// objects, renaming _term to _key where the target removed it.
+func termsOrder(dc DataSource, call, arg string, order map[string]string, orderBy []TermsOrder) (any, error) {
+	if order != nil && orderBy != nil {
+		return nil, &BuildError{call, arg, fmt.Errorf("%w: Order and OrderBy are exclusive", ErrInvalidValue)}
+	}
+	if orderBy == nil {
+		if order == nil {
+			return nil, nil
+		}
+		return renameTermOrder(dc, order), nil
+	}
+
+	list := make([]any, len(orderBy))
+	for i, o := range orderBy {
+		if o.Key == "" {
+			return nil, &BuildError{call, fmt.Sprintf("%s[%d].Key", arg, i), ErrEmptyField}
+		}
+		if o.Order != "asc" && o.Order != "desc" {
+			return nil, &BuildError{call, fmt.Sprintf("%s[%d].Order", arg, i), fmt.Errorf("%w: %q", ErrInvalidValue, o.Order)}
+		}
+		list[i] = renameTermOrder(dc, map[string]string{o.Key: o.Order})
+	}
+	return list, nil
+}
+
+func (m *TermsAggregation) SubAggs(values ...Aggregation) *TermsAggregation {
+	m.subAggs = append(m.subAggs, values...)
+	return m
+}
+
+func (m *TermsAggregation) subAggregations() []Aggregation {
+	return m.subAggs
+}
+
+// renameTermOrder renames _term, deprecated since Elasticsearch 6.0 and
+// removed in 8.0, to _key on 7.0 and later, where both are accepted.
+func renameTermOrder(dc DataSource, o map[string]string) map[string]string {
+	dir, ok := o["_term"]
+	if v, es := dc.esVersion(); !ok || es && !v.atLeast(7, 0) {
+		return o
+	}
+
+	order := make(map[string]string, len(o))
+	for k, v := range o {
+		order[k] = v
+	}
+	delete(order, "_term")
+	order["_key"] = dir
+	return order
+}
+
+func (m *TermsAggregation) deprecations(dc DataSource) []string {
+	return termOrderDeprecations(dc, m.Params.Name, m.Params.Order, m.Params.OrderBy)
+}
+
+func termOrderDeprecations(dc DataSource, name string, order map[string]string, orderBy []TermsOrder) []string {
+	_, ok := order["_term"]
+	for _, o := range orderBy {
+		ok = ok || o.Key == "_term"
+	}
+	if !ok {
+		return nil
+	}
+	v, es := dc.esVersion()
+	switch {
+	case es && !v.atLeast(7, 0):
+		return []string{fmt.Sprintf("aggs.%s: ordering by _term is deprecated on %s, use _key", name, dc)}
+	case es && v.atLeast(8, 0):
+		return []string{fmt.Sprintf("aggs.%s: ordering by _term was removed in Elasticsearch 8.0, rendered as _key", name)}
+	}
+	return []string{fmt.Sprintf("aggs.%s: ordering by _term is deprecated since Elasticsearch 6.0, rendered as _key", name)}
+}
+
+func TermsAgg(params AggregateParams) *TermsAggregation {
+	return &TermsAggregation{Params: params}
+}
+
+type MultiTermsAggregation struct {
+	Params  MultiTermsParams
+	subAggs []Aggregation
+}
+
+type MultiTermsParams struct {
+	Name                  string
+	Terms                 []MultiTermsField
+	Order                 []TermsOrder
+	Size                  int
+	ShardSize             int
+	MinDocCount           *int
+	ShowTermDocCountError bool
+}
+
+type MultiTermsField struct {
+	Field   string
+	Missing any
+}
+
+func (m *MultiTermsAggregation) AggregationName() string {
+	return m.Params.Name
+}
+
+func (m *MultiTermsAggregation) MarshalAggregation(dc DataSource) (any, error) {
+	p := m.Params
+	if v, ok := dc.esVersion(); ok && !v.atLeast(7, 12) {
+		return nil, fmt.Errorf("%w: multi_terms requires Elasticsearch 7.12 or later, got %s", ErrUnsupportedQuery, dc)
+	}
+	if len(p.Terms) < 2 {
+		return nil, &BuildError{"MultiTermsAgg", "params.Terms", fmt.Errorf("%w: at least two terms are required", ErrEmptyValues)}
+	}
+	if p.Size < 0 {
+		return nil, &BuildError{"MultiTermsAgg", "params.Size", ErrNegative}
+	}
+	if p.ShardSize < 0 {
+		return nil, &BuildError{"MultiTermsAgg", "params.ShardSize", ErrNegative}
+	}
+	if p.MinDocCount != nil && *p.MinDocCount < 0 {
+		return nil, &BuildError{"MultiTermsAgg", "params.MinDocCount", ErrNegative}
+	}
+
+	fields := make([]map[string]any, len(p.Terms))
+	for i, t := range p.Terms {
+		if t.Field == "" {
+			return nil, &BuildError{"MultiTermsAgg", fmt.Sprintf("params.Terms[%d].Field", i), ErrEmptyField}
+		}
+		fields[i] = map[string]any{"field": t.Field}
+		setIf(fields[i], "missing", t.Missing, t.Missing != nil)
+	}
+
+	body := map[string]any{"terms": fields}
+	setIf(body, "size", p.Size, p.Size != 0)
+	setIf(body, "shard_size", p.ShardSize, p.ShardSize != 0)
+	if p.MinDocCount != nil {
+		body["min_doc_count"] = *p.MinDocCount
+	}
+	setIf(body, "show_term_doc_count_error", true, p.ShowTermDocCountError)
+	order, err := termsOrder(dc, "MultiTermsAgg", "params.Order", nil, p.Order)
+	if err != nil {
+		return nil, err
+	}
+	setIf(body, "order", order, order != nil)
+
+	return withSubAggs(map[string]any{"multi_terms": body}, m.subAggs, dc, "MultiTermsAggregation.SubAggs")
+}
+
+func (m *MultiTermsAggregation) SubAggs(values ...Aggregation) *MultiTermsAggregation {
+	m.subAggs = append(m.subAggs, values...)
+	return m
+}
+
+func (m *MultiTermsAggregation) subAggregations() []Aggregation {
+	return m.subAggs
+}
+
+// MultiTermsAgg buckets on combinations of several fields.
+func MultiTermsAgg(params MultiTermsParams) *MultiTermsAggregation {
+	return &MultiTermsAggregation{Params: params}
+}
+
+type RawAggregation struct {
+	Name string
+	JSON json.RawMessage
+}
+
+func (r *RawAggregation) AggregationName() string {
+	return r.Name
+}
+
+func (r *RawAggregation) MarshalAggregation(dc DataSource) (any, error) {
+	if !json.Valid(r.JSON) {
+		return nil, fmt.Errorf("queryBuilder: invalid raw aggregation %q", r.JSON)
+	}
+	return r.JSON, nil
+}
+
+// RawAgg embeds an aggregation body, e.g. {"avg":{"field":"score"}}, verbatim.
+func RawAgg(name string, body json.RawMessage) Aggregation {
+	return &RawAggregation{name, body}
+}
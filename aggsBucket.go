@@ -0,0 +1,349 @@
+package queryBuilder
+
+import (
+	"errors"
+	"fmt"
+)
+
+type Bounds struct {
+	Min any `json:"min,omitempty"`
+	Max any `json:"max,omitempty"`
+}
+
+type DateHistogramParams struct {
+	Name             string
+	Field            string
+	CalendarInterval string // e.g. 1d, 1w, 1M
+	FixedInterval    string // e.g. 30m, 12h, 90s
+	TimeZone         string
+	Format           string
+	Offset           string
+	MinDocCount      int
+	ExtendedBounds   *Bounds
+	Missing          any
+	Keyed            bool
+}
+
+type DateHistogramAggregation struct {
+	Params  DateHistogramParams
+	subAggs []Aggregation
+}
+
+func (d *DateHistogramAggregation) AggregationName() string {
+	return d.Params.Name
+}
+
+func (d *DateHistogramAggregation) MarshalAggregation(dc DataSource) (any, error) {
+	p := d.Params
+	if err := requireField("DateHistogramAgg", p.Field); err != nil {
+		return nil, err
+	}
+	if (p.CalendarInterval == "") == (p.FixedInterval == "") {
+		return nil, &BuildError{"DateHistogramAgg", "params.CalendarInterval", fmt.Errorf("%w: set exactly one of CalendarInterval and FixedInterval", ErrInvalidValue)}
+	}
+
+	body := map[string]any{"field": p.Field}
+	switch v, ok := dc.esVersion(); {
+	case ok && !v.atLeast(7, 2):
+		// calendar_interval and fixed_interval were split out of interval in 7.2.
+		body["interval"] = p.CalendarInterval + p.FixedInterval
+	case p.CalendarInterval != "":
+		body["calendar_interval"] = p.CalendarInterval
+	default:
+		body["fixed_interval"] = p.FixedInterval
+	}
+	setIf(body, "time_zone", p.TimeZone, p.TimeZone != "")
+	setIf(body, "format", p.Format, p.Format != "")
+	setIf(body, "offset", p.Offset, p.Offset != "")
+	setIf(body, "min_doc_count", p.MinDocCount, p.MinDocCount != 0)
+	setIf(body, "extended_bounds", p.ExtendedBounds, p.ExtendedBounds != nil)
+	setIf(body, "missing", p.Missing, p.Missing != nil)
+	setIf(body, "keyed", p.Keyed, p.Keyed)
+
+	return withSubAggs(map[string]any{"date_histogram": body}, d.subAggs, dc, "DateHistogramAggregation.SubAggs")
+}
+
+func (d *DateHistogramAggregation) SubAggs(values ...Aggregation) *DateHistogramAggregation {
+	d.subAggs = append(d.subAggs, values...)
+	return d
+}
+
+func (d *DateHistogramAggregation) subAggregations() []Aggregation {
+	return d.subAggs
+}
+
+func DateHistogramAgg(params DateHistogramParams) *DateHistogramAggregation {
+	return &DateHistogramAggregation{Params: params}
+}
+
+type HistogramParams struct {
+	Name           string
+	Field          string
+	Interval       float64
+	Offset         float64
+	MinDocCount    int
+	ExtendedBounds *Bounds
+	Missing        any
+	Keyed          bool
+}
+
+type HistogramAggregation struct {
+	Params  HistogramParams
+	subAggs []Aggregation
+}
+
+func (h *HistogramAggregation) AggregationName() string {
+	return h.Params.Name
+}
+
+func (h *HistogramAggregation) MarshalAggregation(dc DataSource) (any, error) {
+	p := h.Params
+	if err := requireField("HistogramAgg", p.Field); err != nil {
+		return nil, err
+	}
+	if p.Interval <= 0 {
+		return nil, &BuildError{"HistogramAgg", "params.Interval", fmt.Errorf("%w: interval must be positive", ErrInvalidValue)}
+	}
+
+	body := map[string]any{"field": p.Field, "interval": p.Interval}
+	setIf(body, "offset", p.Offset, p.Offset != 0)
+	setIf(body, "min_doc_count", p.MinDocCount, p.MinDocCount != 0)
+	setIf(body, "extended_bounds", p.ExtendedBounds, p.ExtendedBounds != nil)
+	setIf(body, "missing", p.Missing, p.Missing != nil)
+	setIf(body, "keyed", p.Keyed, p.Keyed)
+
+	return withSubAggs(map[string]any{"histogram": body}, h.subAggs, dc, "HistogramAggregation.SubAggs")
+}
+
+func (h *HistogramAggregation) SubAggs(values ...Aggregation) *HistogramAggregation {
+	h.subAggs = append(h.subAggs, values...)
+	return h
+}
+
+func (h *HistogramAggregation) subAggregations() []Aggregation {
+	return h.subAggs
+}
+
+func HistogramAgg(params HistogramParams) *HistogramAggregation {
+	return &HistogramAggregation{Params: params}
+}
+
+type AggRange struct {
+	Key  string `json:"key,omitempty"`
+	From any    `json:"from,omitempty"`
+	To   any    `json:"to,omitempty"`
+}
+
+type RangeAggParams struct {
+	Name     string
+	Field    string
+	Ranges   []AggRange
+	Keyed    bool
+	Format   string // date_range only
+	TimeZone string // date_range only
+	Missing  any
+}
+
+type RangeAggregation struct {
+	Type    string // range or date_range
+	Params  RangeAggParams
+	subAggs []Aggregation
+}
+
+func (r *RangeAggregation) AggregationName() string {
+	return r.Params.Name
+}
+
+func (r *RangeAggregation) MarshalAggregation(dc DataSource) (any, error) {
+	call := "RangeAgg"
+	if r.Type == "date_range" {
+		call = "DateRangeAgg"
+	}
+	p := r.Params
+	if err := requireField(call, p.Field); err != nil {
+		return nil, err
+	}
+	if len(p.Ranges) == 0 {
+		return nil, &BuildError{call, "params.Ranges", ErrEmptyValues}
+	}
+
+	body := map[string]any{"field": p.Field, "ranges": p.Ranges}
+	setIf(body, "keyed", p.Keyed, p.Keyed)
+	setIf(body, "format", p.Format, p.Format != "")
+	setIf(body, "time_zone", p.TimeZone, p.TimeZone != "")
+	setIf(body, "missing", p.Missing, p.Missing != nil)
+
+	return withSubAggs(map[string]any{r.Type: body}, r.subAggs, dc, "RangeAggregation.SubAggs")
+}
+
+func (r *RangeAggregation) SubAggs(values ...Aggregation) *RangeAggregation {
+	r.subAggs = append(r.subAggs, values...)
+	return r
+}
+
+func (r *RangeAggregation) subAggregations() []Aggregation {
+	return r.subAggs
+}
+
+func RangeAgg(params RangeAggParams) *RangeAggregation {
+	return &RangeAggregation{Type: "range", Params: params}
+}
+
+func DateRangeAgg(params RangeAggParams) *RangeAggregation {
+	return &RangeAggregation{Type: "date_range", Params: params}
+}
+
+type FilterAggregation struct {
+	Name    string
+	Filter  Query
+	subAggs []Aggregation
+}
+
+func (f *FilterAggregation) AggregationName() string {
+	return f.Name
+}
+
+func (f *FilterAggregation) MarshalAggregation(dc DataSource) (any, error) {
+	filter, err := render(f.Filter, dc, "FilterAgg", "filter")
+	if err != nil {
+		return nil, err
+	}
+	return withSubAggs(map[string]any{"filter": filter}, f.subAggs, dc, "FilterAggregation.SubAggs")
+}
+
+func (f *FilterAggregation) SubAggs(values ...Aggregation) *FilterAggregation {
+	f.subAggs = append(f.subAggs, values...)
+	return f
+}
+
+func (f *FilterAggregation) subAggregations() []Aggregation {
+	return f.subAggs
+}
+
+func FilterAgg(name string, filter Query) *FilterAggregation {
+	return &FilterAggregation{Name: name, Filter: filter}
+}
+
+type NamedFilter struct {
+	Name   string
+	Filter Query
+}
+
+type FiltersAggregation struct {
+	Name           string
+	Filters        []NamedFilter
+	OtherBucketKey string // enables the other bucket under this key
+	subAggs        []Aggregation
+}
+
+func (f *FiltersAggregation) AggregationName() string {
+	return f.Name
+}
+
+func (f *FiltersAggregation) MarshalAggregation(dc DataSource) (any, error) {
+	if len(f.Filters) == 0 {
+		return nil, &BuildError{"FiltersAgg", "filters", ErrEmptyValues}
+	}
+
+	var errs []error
+	filters := make(map[string]any, len(f.Filters))
+	for i, nf := range f.Filters {
+		arg := fmt.Sprintf("filters[%d]", i)
+		if nf.Name == "" {
+			errs = append(errs, &BuildError{"FiltersAgg", arg, ErrEmptyName})
+			continue
+		}
+		if _, dup := filters[nf.Name]; dup {
+			errs = append(errs, &BuildError{"FiltersAgg", arg, fmt.Errorf("%w: %q", ErrDuplicateName, nf.Name)})
+			continue
+		}
+		filter, err := render(nf.Filter, dc, "FiltersAgg", arg)
+		errs = append(errs, err)
+		filters[nf.Name] = filter
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	body := map[string]any{"filters": filters}
+	setIf(body, "other_bucket_key", f.OtherBucketKey, f.OtherBucketKey != "")
+
+	return withSubAggs(map[string]any{"filters": body}, f.subAggs, dc, "FiltersAggregation.SubAggs")
+}
+
+func (f *FiltersAggregation) SubAggs(values ...Aggregation) *FiltersAggregation {
+	f.subAggs = append(f.subAggs, values...)
+	return f
+}
+
+func (f *FiltersAggregation) subAggregations() []Aggregation {
+	return f.subAggs
+}
+
+func FiltersAgg(name string, filters ...NamedFilter) *FiltersAggregation {
+	return &FiltersAggregation{Name: name, Filters: filters}
+}
+
+type MissingAggregation struct {
+	Name    string
+	Field   string
+	subAggs []Aggregation
+}
+
+func (m *MissingAggregation) AggregationName() string {
+	return m.Name
+}
+
+func (m *MissingAggregation) MarshalAggregation(dc DataSource) (any, error) {
+	if err := requireField("MissingAgg", m.Field); err != nil {
+		return nil, err
+	}
+	return withSubAggs(map[string]any{"missing": map[string]any{"field": m.Field}}, m.subAggs, dc, "MissingAggregation.SubAggs")
+}
+
+func (m *MissingAggregation) SubAggs(values ...Aggregation) *MissingAggregation {
+	m.subAggs = append(m.subAggs, values...)
+	return m
+}
+
+func (m *MissingAggregation) subAggregations() []Aggregation {
+	return m.subAggs
+}
+
+func MissingAgg(name string, field string) *MissingAggregation {
+	return &MissingAggregation{Name: name, Field: field}
+}
+
+// GlobalAggregation buckets every document of the index, ignoring the query.
+// It is only valid at the top level.
+type GlobalAggregation struct {
+	Name    string
+	subAggs []Aggregation
+}
+
+func (g *GlobalAggregation) AggregationName() string {
+	return g.Name
+}
+
+func (g *GlobalAggregation) MarshalAggregation(dc DataSource) (any, error) {
+	return withSubAggs(map[string]any{"global": struct{}{}}, g.subAggs, dc, "GlobalAggregation.SubAggs")
+}
+
+func (g *GlobalAggregation) SubAggs(values ...Aggregation) *GlobalAggregation {
+	g.subAggs = append(g.subAggs, values...)
+	return g
+}
+
+func (g *GlobalAggregation) subAggregations() []Aggregation {
+	return g.subAggs
+}
+
+func GlobalAgg(name string) *GlobalAggregation {
+	return &GlobalAggregation{Name: name}
+}
+
+func setIf(m map[string]any, key string, value any, ok bool) {
+	if ok {
+		m[key] = value
+	}
+}
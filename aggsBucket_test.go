@@ -0,0 +1,216 @@
+package queryBuilder_test
+
+import (
+	"testing"
+
+	"github.com/linksports/queryBuilder"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAggsBucket(t *testing.T) {
+	t.Run("date_histogram", func(t *testing.T) {
+		builder := queryBuilder.New().Aggs(
+			queryBuilder.DateHistogramAgg(queryBuilder.DateHistogramParams{
+				Name:             "per_day",
+				Field:            "played_at",
+				CalendarInterval: "1d",
+				TimeZone:         "Asia/Tokyo",
+				MinDocCount:      1,
+				ExtendedBounds:   &queryBuilder.Bounds{Min: "2024-04-01", Max: "2024-04-30"},
+			}).SubAggs(
+				queryBuilder.SumAgg(queryBuilder.MetricParams{Name: "goals", Field: "goals"}),
+			),
+		)
+
+		query, err := builder.Build(queryBuilder.ES)
+		assert.NoError(t, err)
+		assert.Equal(t, queryBuilder.Trim(`{
+			"aggs":{
+				"per_day":{
+					"aggs":{
+						"goals":{"sum":{"field":"goals"}}
+					},
+					"date_histogram":{
+						"calendar_interval":"1d",
+						"extended_bounds":{"min":"2024-04-01","max":"2024-04-30"},
+						"field":"played_at",
+						"min_doc_count":1,
+						"time_zone":"Asia/Tokyo"
+					}
+				}
+			}
+		}`), query)
+
+		query, err = builder.Build(queryBuilder.ES6)
+		assert.NoError(t, err)
+		assert.Contains(t, query, `"interval":"1d"`)
+		assert.NotContains(t, query, `calendar_interval`)
+
+		_, err = queryBuilder.New().Aggs(
+			queryBuilder.DateHistogramAgg(queryBuilder.DateHistogramParams{
+				Name:             "both",
+				Field:            "played_at",
+				CalendarInterval: "1d",
+				FixedInterval:    "24h",
+			}),
+		).Build(queryBuilder.ES)
+		assert.ErrorIs(t, err, queryBuilder.ErrInvalidValue)
+	})
+
+	t.Run("histogram", func(t *testing.T) {
+		query, err := queryBuilder.New().Aggs(
+			queryBuilder.HistogramAgg(queryBuilder.HistogramParams{
+				Name:     "ages",
+				Field:    "age",
+				Interval: 10,
+				Keyed:    true,
+				Missing:  0,
+			}),
+		).Build(queryBuilder.ES)
+
+		assert.NoError(t, err)
+		assert.Equal(t, `{"aggs":{"ages":{"histogram":{"field":"age","interval":10,"keyed":true,"missing":0}}}}`, query)
+
+		_, err = queryBuilder.New().Aggs(
+			queryBuilder.HistogramAgg(queryBuilder.HistogramParams{Name: "ages", Field: "age"}),
+		).Build(queryBuilder.ES)
+		assert.ErrorIs(t, err, queryBuilder.ErrInvalidValue)
+	})
+
+	t.Run("range+date_range", func(t *testing.T) {
+		query, err := queryBuilder.New().Aggs(
+			queryBuilder.RangeAgg(queryBuilder.RangeAggParams{
+				Name:  "age_groups",
+				Field: "age",
+				Keyed: true,
+				Ranges: []queryBuilder.AggRange{
+					{Key: "junior", To: 13},
+					{Key: "youth", From: 13, To: 19},
+					{Key: "adult", From: 19},
+				},
+			}),
+			queryBuilder.DateRangeAgg(queryBuilder.RangeAggParams{
+				Name:     "seasons",
+				Field:    "played_at",
+				Format:   "yyyy-MM",
+				TimeZone: "Asia/Tokyo",
+				Ranges: []queryBuilder.AggRange{
+					{From: "now-1y/y", To: "now/y"},
+				},
+			}),
+		).Build(queryBuilder.ES)
+
+		assert.NoError(t, err)
+		assert.Equal(t, queryBuilder.Trim(`{
+			"aggs":{
+				"age_groups":{
+					"range":{
+						"field":"age",
+						"keyed":true,
+						"ranges":[
+							{"key":"junior","to":13},
+							{"key":"youth","from":13,"to":19},
+							{"key":"adult","from":19}
+						]
+					}
+				},
+				"seasons":{
+					"date_range":{
+						"field":"played_at",
+						"format":"yyyy-MM",
+						"ranges":[{"from":"now-1y/y","to":"now/y"}],
+						"time_zone":"Asia/Tokyo"
+					}
+				}
+			}
+		}`), query)
+
+		_, err = queryBuilder.New().Aggs(
+			queryBuilder.RangeAgg(queryBuilder.RangeAggParams{Name: "empty", Field: "age"}),
+		).Build(queryBuilder.ES)
+		assert.ErrorIs(t, err, queryBuilder.ErrEmptyValues)
+	})
+
+	t.Run("filter+filters", func(t *testing.T) {
+		query, err := queryBuilder.New().Aggs(
+			queryBuilder.FilterAgg("active", queryBuilder.Term("status", "active")).SubAggs(
+				queryBuilder.FiltersAgg("kinds",
+					queryBuilder.NamedFilter{Name: "clubs", Filter: queryBuilder.Term("kind", "club")},
+					queryBuilder.NamedFilter{Name: "schools", Filter: queryBuilder.Bool().Should(
+						queryBuilder.Term("kind", "high_school"),
+						queryBuilder.Term("kind", "university"),
+					)},
+				).SubAggs(
+					queryBuilder.AvgAgg(queryBuilder.MetricParams{Name: "avg_members", Field: "members"}),
+				),
+			),
+		).Build(queryBuilder.ES)
+
+		assert.NoError(t, err)
+		assert.Equal(t, queryBuilder.Trim(`{
+			"aggs":{
+				"active":{
+					"aggs":{
+						"kinds":{
+							"aggs":{
+								"avg_members":{"avg":{"field":"members"}}
+							},
+							"filters":{
+								"filters":{
+									"clubs":{"term":{"kind":"club"}},
+									"schools":{"bool":{"should":[{"term":{"kind":"high_school"}},{"term":{"kind":"university"}}]}}
+								}
+							}
+						}
+					},
+					"filter":{"term":{"status":"active"}}
+				}
+			}
+		}`), query)
+
+		_, err = queryBuilder.New().Aggs(
+			queryBuilder.FiltersAgg("kinds",
+				queryBuilder.NamedFilter{Name: "a", Filter: queryBuilder.Term("", "v")},
+				queryBuilder.NamedFilter{Name: "a", Filter: queryBuilder.MatchAll()},
+			),
+		).Build(queryBuilder.ES)
+		assert.ErrorIs(t, err, queryBuilder.ErrEmptyField)
+		assert.ErrorIs(t, err, queryBuilder.ErrDuplicateName)
+	})
+
+	t.Run("missing+global", func(t *testing.T) {
+		filters := queryBuilder.FiltersAgg("kinds", queryBuilder.NamedFilter{Name: "clubs", Filter: queryBuilder.Term("kind", "club")})
+		filters.OtherBucketKey = "others"
+
+		query, err := queryBuilder.New().Query(
+			queryBuilder.Term("city", "tokyo"),
+		).Aggs(
+			queryBuilder.GlobalAgg("all").SubAggs(
+				queryBuilder.MissingAgg("no_logo", "logo"),
+				filters,
+			),
+		).Build(queryBuilder.ES)
+
+		assert.NoError(t, err)
+		assert.Equal(t, queryBuilder.Trim(`{
+			"query":{"term":{"city":"tokyo"}},
+			"aggs":{
+				"all":{
+					"aggs":{
+						"kinds":{"filters":{"filters":{"clubs":{"term":{"kind":"club"}}},"other_bucket_key":"others"}},
+						"no_logo":{"missing":{"field":"logo"}}
+					},
+					"global":{}
+				}
+			}
+		}`), query)
+
+		_, err = queryBuilder.New().Aggs(
+			queryBuilder.TermsAgg(queryBuilder.AggregateParams{Name: "teams", FieldName: "team_id"}).SubAggs(
+				queryBuilder.GlobalAgg("all"),
+			),
+		).Build(queryBuilder.ES)
+		assert.ErrorIs(t, err, queryBuilder.ErrWrongNodeKind)
+		assert.ErrorContains(t, err, "Builder.Aggs(values[0]): TermsAggregation.SubAggs(values[0])")
+	})
+}
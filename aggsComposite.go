@@ -0,0 +1,153 @@
+package queryBuilder
+
+import (
+	"fmt"
+)
+
+type CompositeSource struct {
+	Name             string
+	Type             string // terms, histogram or date_histogram
+	Field            string
+	Interval         float64 // histogram
+	CalendarInterval string  // date_histogram
+	FixedInterval    string  // date_histogram
+	TimeZone         string  // date_histogram
+	Format           string  // date_histogram
+	Order            string  // asc or desc
+	MissingBucket    bool
+}
+
+func TermsSource(name, field string) CompositeSource {
+	return CompositeSource{Name: name, Type: "terms", Field: field}
+}
+
+func HistogramSource(name, field string, interval float64) CompositeSource {
+	return CompositeSource{Name: name, Type: "histogram", Field: field, Interval: interval}
+}
+
+func DateHistogramSource(name, field, calendarInterval string) CompositeSource {
+	return CompositeSource{Name: name, Type: "date_histogram", Field: field, CalendarInterval: calendarInterval}
+}
+
+func (s CompositeSource) generate(dc DataSource, arg string) (any, error) {
+	if s.Name == "" {
+		return nil, &BuildError{"CompositeAgg", arg + ".Name", ErrEmptyName}
+	}
+	if s.Field == "" {
+		return nil, &BuildError{"CompositeAgg", arg + ".Field", ErrEmptyField}
+	}
+
+	body := map[string]any{"field": s.Field}
+	switch s.Type {
+	case "terms":
+	case "histogram":
+		if s.Interval <= 0 {
+			return nil, &BuildError{"CompositeAgg", arg + ".Interval", fmt.Errorf("%w: interval must be positive", ErrInvalidValue)}
+		}
+		body["interval"] = s.Interval
+	case "date_histogram":
+		if (s.CalendarInterval == "") == (s.FixedInterval == "") {
+			return nil, &BuildError{"CompositeAgg", arg + ".CalendarInterval", fmt.Errorf("%w: set exactly one of CalendarInterval and FixedInterval", ErrInvalidValue)}
+		}
+		switch v, ok := dc.esVersion(); {
+		case ok && !v.atLeast(7, 2):
+			body["interval"] = s.CalendarInterval + s.FixedInterval
+		case s.CalendarInterval != "":
+			body["calendar_interval"] = s.CalendarInterval
+		default:
+			body["fixed_interval"] = s.FixedInterval
+		}
+		setIf(body, "time_zone", s.TimeZone, s.TimeZone != "")
+		setIf(body, "format", s.Format, s.Format != "")
+	default:
+		return nil, &BuildError{"CompositeAgg", arg + ".Type", fmt.Errorf("%w: unknown source type %q", ErrInvalidValue, s.Type)}
+	}
+	setIf(body, "order", s.Order, s.Order != "")
+	setIf(body, "missing_bucket", s.MissingBucket, s.MissingBucket)
+
+	return map[string]any{s.Name: map[string]any{s.Type: body}}, nil
+}
+
+type CompositeParams struct {
+	Name    string
+	Size    int
+	Sources []CompositeSource
+	After   map[string]any
+}
+
+type CompositeAggregation struct {
+	Params  CompositeParams
+	subAggs []Aggregation
+}
+
+func (c *CompositeAggregation) AggregationName() string {
+	return c.Params.Name
+}
+
+func (c *CompositeAggregation) MarshalAggregation(dc DataSource) (any, error) {
+	if v, ok := dc.esVersion(); ok && !v.atLeast(6, 1) {
+		return nil, fmt.Errorf("%w: composite requires Elasticsearch 6.1 or later, got %s", ErrUnsupportedQuery, dc)
+	}
+	if len(c.Params.Sources) == 0 {
+		return nil, &BuildError{"CompositeAgg", "params.Sources", ErrEmptyValues}
+	}
+	if c.Params.Size < 0 {
+		return nil, &BuildError{"CompositeAgg", "params.Size", ErrNegative}
+	}
+
+	sources := make([]any, len(c.Params.Sources))
+	for i, s := range c.Params.Sources {
+		source, err := s.generate(dc, fmt.Sprintf("params.Sources[%d]", i))
+		if err != nil {
+			return nil, err
+		}
+		sources[i] = source
+	}
+
+	body := map[string]any{"sources": sources}
+	setIf(body, "size", c.Params.Size, c.Params.Size != 0)
+	setIf(body, "after", c.Params.After, len(c.Params.After) > 0)
+
+	return withSubAggs(map[string]any{"composite": body}, c.subAggs, dc, "CompositeAggregation.SubAggs")
+}
+
+func (c *CompositeAggregation) SubAggs(values ...Aggregation) *CompositeAggregation {
+	c.subAggs = append(c.subAggs, values...)
+	return c
+}
+
+func (c *CompositeAggregation) subAggregations() []Aggregation {
+	return c.subAggs
+}
+
+func CompositeAgg(params CompositeParams) *CompositeAggregation {
+	return &CompositeAggregation{Params: params}
+}
+
+// NextPage returns a copy of b whose top-level composite aggregation aggName
+// resumes after afterKey, the after_key of the previous response. It returns
+// nil once afterKey is empty, i.e. after the last page:
+//
+//	for page := builder; page != nil; {
+//		// search with page.Build(...) and read after_key
+//		page, err = page.NextPage("teams", afterKey)
+//	}
+func (b *Builder) NextPage(aggName string, afterKey map[string]any) (*Builder, error) {
+	if len(afterKey) == 0 {
+		return nil, nil
+	}
+
+	for i, a := range b.aggs {
+		c, ok := a.(*CompositeAggregation)
+		if !ok || c.Params.Name != aggName {
+			continue
+		}
+		next := *c
+		next.Params.After = afterKey
+
+		page := b.Clone()
+		page.aggs[i] = &next
+		return page, nil
+	}
+	return nil, &BuildError{"Builder.NextPage", "aggName", fmt.Errorf("%w: no composite aggregation %q", ErrInvalidValue, aggName)}
+}
@@ -0,0 +1,125 @@
+package queryBuilder_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/linksports/queryBuilder"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAggsComposite(t *testing.T) {
+	newBuilder := func() *queryBuilder.Builder {
+		season := queryBuilder.DateHistogramSource("season", "played_at", "1y")
+		season.Order = "desc"
+		season.TimeZone = "Asia/Tokyo"
+
+		return queryBuilder.New().Size(0).Aggs(
+			queryBuilder.CompositeAgg(queryBuilder.CompositeParams{
+				Name: "teams",
+				Size: 2,
+				Sources: []queryBuilder.CompositeSource{
+					queryBuilder.TermsSource("league", "league_id"),
+					season,
+					queryBuilder.TermsSource("team", "team_id"),
+				},
+			}).SubAggs(
+				queryBuilder.AvgAgg(queryBuilder.MetricParams{Name: "avg_score", Field: "score"}),
+			),
+		)
+	}
+
+	t.Run("composite", func(t *testing.T) {
+		query, err := newBuilder().Build(queryBuilder.ES)
+
+		assert.NoError(t, err)
+		assert.Equal(t, queryBuilder.Trim(`{
+			"aggs":{
+				"teams":{
+					"aggs":{
+						"avg_score":{"avg":{"field":"score"}}
+					},
+					"composite":{
+						"size":2,
+						"sources":[
+							{"league":{"terms":{"field":"league_id"}}},
+							{"season":{"date_histogram":{"calendar_interval":"1y","field":"played_at","order":"desc","time_zone":"Asia/Tokyo"}}},
+							{"team":{"terms":{"field":"team_id"}}}
+						]
+					}
+				}
+			}
+		}`), query)
+	})
+
+	t.Run("histogram source", func(t *testing.T) {
+		age := queryBuilder.HistogramSource("age", "age", 5)
+		age.MissingBucket = true
+
+		query, err := queryBuilder.New().Aggs(
+			queryBuilder.CompositeAgg(queryBuilder.CompositeParams{
+				Name:    "ages",
+				Sources: []queryBuilder.CompositeSource{age},
+			}),
+		).Build(queryBuilder.ES)
+
+		assert.NoError(t, err)
+		assert.Equal(t, `{"aggs":{"ages":{"composite":{"sources":[{"age":{"histogram":{"field":"age","interval":5,"missing_bucket":true}}}]}}}}`, query)
+	})
+
+	t.Run("next page", func(t *testing.T) {
+		responses := []string{
+			`{"after_key":{"league":1,"season":1704034800000,"team":"b"}}`,
+			`{"after_key":{"league":2,"season":1704034800000,"team":"c"}}`,
+			`{}`,
+		}
+
+		var afters []string
+		page := newBuilder()
+		for i := 0; page != nil; i++ {
+			query, err := page.Build(queryBuilder.ES)
+			assert.NoError(t, err)
+
+			var body struct {
+				Aggs map[string]struct {
+					Composite struct {
+						After json.RawMessage `json:"after"`
+					} `json:"composite"`
+				} `json:"aggs"`
+			}
+			assert.NoError(t, json.Unmarshal([]byte(query), &body))
+			afters = append(afters, string(body.Aggs["teams"].Composite.After))
+
+			var response struct {
+				AfterKey map[string]any `json:"after_key"`
+			}
+			d := json.NewDecoder(strings.NewReader(responses[i]))
+			d.UseNumber()
+			assert.NoError(t, d.Decode(&response))
+
+			page, err = page.NextPage("teams", response.AfterKey)
+			assert.NoError(t, err)
+		}
+
+		assert.Equal(t, []string{
+			``,
+			`{"league":1,"season":1704034800000,"team":"b"}`,
+			`{"league":2,"season":1704034800000,"team":"c"}`,
+		}, afters)
+	})
+
+	t.Run("next page errors", func(t *testing.T) {
+		_, err := newBuilder().NextPage("unknown", map[string]any{"team": "a"})
+		assert.ErrorIs(t, err, queryBuilder.ErrInvalidValue)
+	})
+
+	t.Run("versions", func(t *testing.T) {
+		query, err := newBuilder().Build(queryBuilder.ESVersion("6.8"))
+		assert.NoError(t, err)
+		assert.Contains(t, query, `{"season":{"date_histogram":{"field":"played_at","interval":"1y","order":"desc","time_zone":"Asia/Tokyo"}}}`)
+
+		_, err = newBuilder().Build(queryBuilder.ESVersion("6.0"))
+		assert.ErrorIs(t, err, queryBuilder.ErrUnsupportedQuery)
+	})
+}
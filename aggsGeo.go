@@ -0,0 +1,171 @@
+package queryBuilder
+
+import "fmt"
+
+type GeoGridParams struct {
+	Name      string
+	Field     string
+	Precision *int // 1-12 for geohash_grid, 0-29 for geotile_grid; nil keeps the server default
+	Size      int
+	ShardSize int
+}
+
+type GeoGridAggregation struct {
+	Type    string // geohash_grid or geotile_grid
+	Params  GeoGridParams
+	subAggs []Aggregation
+}
+
+func (g *GeoGridAggregation) AggregationName() string {
+	return g.Params.Name
+}
+
+func (g *GeoGridAggregation) MarshalAggregation(dc DataSource) (any, error) {
+	call, low, high := "GeohashGridAgg", 1, 12
+	if g.Type == "geotile_grid" {
+		call, low, high = "GeotileGridAgg", 0, 29
+		if v, ok := dc.esVersion(); ok && !v.atLeast(7, 0) {
+			return nil, fmt.Errorf("%w: geotile_grid requires Elasticsearch 7.0 or later, got %s", ErrUnsupportedQuery, dc)
+		}
+	}
+	p := g.Params
+	if err := requireField(call, p.Field); err != nil {
+		return nil, err
+	}
+	if p.Precision != nil && (*p.Precision < low || *p.Precision > high) {
+		return nil, &BuildError{call, "params.Precision", fmt.Errorf("%w: %d is not in %d-%d", ErrInvalidValue, *p.Precision, low, high)}
+	}
+	if p.Size < 0 || p.ShardSize < 0 {
+		return nil, &BuildError{call, "params.Size", ErrNegative}
+	}
+
+	body := map[string]any{"field": p.Field}
+	if p.Precision != nil {
+		body["precision"] = *p.Precision
+	}
+	setIf(body, "size", p.Size, p.Size != 0)
+	setIf(body, "shard_size", p.ShardSize, p.ShardSize != 0)
+
+	return withSubAggs(map[string]any{g.Type: body}, g.subAggs, dc, "GeoGridAggregation.SubAggs")
+}
+
+func (g *GeoGridAggregation) SubAggs(values ...Aggregation) *GeoGridAggregation {
+	g.subAggs = append(g.subAggs, values...)
+	return g
+}
+
+func (g *GeoGridAggregation) subAggregations() []Aggregation {
+	return g.subAggs
+}
+
+func GeohashGridAgg(params GeoGridParams) *GeoGridAggregation {
+	return &GeoGridAggregation{Type: "geohash_grid", Params: params}
+}
+
+func GeotileGridAgg(params GeoGridParams) *GeoGridAggregation {
+	return &GeoGridAggregation{Type: "geotile_grid", Params: params}
+}
+
+type GeoBoundsAggregation struct {
+	Name          string
+	Field         string
+	WrapLongitude *bool
+}
+
+func (g *GeoBoundsAggregation) AggregationName() string {
+	return g.Name
+}
+
+func (g *GeoBoundsAggregation) MarshalAggregation(dc DataSource) (any, error) {
+	if err := requireField("GeoBoundsAgg", g.Field); err != nil {
+		return nil, err
+	}
+	body := map[string]any{"field": g.Field}
+	if g.WrapLongitude != nil {
+		body["wrap_longitude"] = *g.WrapLongitude
+	}
+	return map[string]any{"geo_bounds": body}, nil
+}
+
+func GeoBoundsAgg(name string, field string) *GeoBoundsAggregation {
+	return &GeoBoundsAggregation{Name: name, Field: field}
+}
+
+type GeoCentroidAggregation struct {
+	Name  string
+	Field string
+}
+
+func (g *GeoCentroidAggregation) AggregationName() string {
+	return g.Name
+}
+
+func (g *GeoCentroidAggregation) MarshalAggregation(dc DataSource) (any, error) {
+	if err := requireField("GeoCentroidAgg", g.Field); err != nil {
+		return nil, err
+	}
+	return map[string]any{"geo_centroid": map[string]any{"field": g.Field}}, nil
+}
+
+func GeoCentroidAgg(name string, field string) *GeoCentroidAggregation {
+	return &GeoCentroidAggregation{Name: name, Field: field}
+}
+
+type GeoDistanceAggParams struct {
+	Name         string
+	Field        string
+	Origin       GeoPoint
+	Unit         DistanceUnit
+	DistanceType string // arc or plane
+	Ranges       []AggRange
+	Keyed        bool
+}
+
+// GeoDistanceAggregation buckets documents by their distance from Origin.
+type GeoDistanceAggregation struct {
+	Params  GeoDistanceAggParams
+	subAggs []Aggregation
+}
+
+func (g *GeoDistanceAggregation) AggregationName() string {
+	return g.Params.Name
+}
+
+func (g *GeoDistanceAggregation) MarshalAggregation(dc DataSource) (any, error) {
+	p := g.Params
+	if err := requireField("GeoDistanceAgg", p.Field); err != nil {
+		return nil, err
+	}
+	if len(p.Ranges) == 0 {
+		return nil, &BuildError{"GeoDistanceAgg", "params.Ranges", ErrEmptyValues}
+	}
+	origin, err := p.Origin.generate("GeoDistanceAgg", "params.Origin")
+	if err != nil {
+		return nil, err
+	}
+	if p.Unit != "" {
+		if _, err := (Distance{1, p.Unit}).generate("GeoDistanceAgg", "params.Unit"); err != nil {
+			return nil, err
+		}
+	}
+
+	body := map[string]any{"field": p.Field, "origin": origin, "ranges": p.Ranges}
+	setIf(body, "unit", p.Unit, p.Unit != "")
+	setIf(body, "distance_type", p.DistanceType, p.DistanceType != "")
+	setIf(body, "keyed", p.Keyed, p.Keyed)
+
+	return withSubAggs(map[string]any{"geo_distance": body}, g.subAggs, dc, "GeoDistanceAggregation.SubAggs")
+}
+
+func (g *GeoDistanceAggregation) SubAggs(values ...Aggregation) *GeoDistanceAggregation {
+	g.subAggs = append(g.subAggs, values...)
+	return g
+}
+
+func (g *GeoDistanceAggregation) subAggregations() []Aggregation {
+	return g.subAggs
+}
+
+func GeoDistanceAgg(params GeoDistanceAggParams) *GeoDistanceAggregation {
+	return &GeoDistanceAggregation{Params: params}
+}
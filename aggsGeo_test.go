@@ -0,0 +1,88 @@
+package queryBuilder_test
+
+import (
+	"testing"
+
+	"github.com/linksports/queryBuilder"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAggsGeo(t *testing.T) {
+	precision := func(v int) *int { return &v }
+
+	t.Run("grids", func(t *testing.T) {
+		query, err := queryBuilder.New().Aggs(
+			queryBuilder.GeohashGridAgg(queryBuilder.GeoGridParams{Name: "cells", Field: "location", Precision: precision(5)}).SubAggs(
+				queryBuilder.GeoCentroidAgg("center", "location"),
+			),
+			queryBuilder.GeotileGridAgg(queryBuilder.GeoGridParams{Name: "tiles", Field: "location", Precision: precision(8), Size: 100}),
+			queryBuilder.GeoBoundsAgg("viewport", "location"),
+		).Size(0).Build(queryBuilder.ES)
+
+		assert.NoError(t, err)
+		assert.Equal(t, queryBuilder.Trim(`{
+			"aggs":{
+				"cells":{
+					"aggs":{
+						"center":{"geo_centroid":{"field":"location"}}
+					},
+					"geohash_grid":{"field":"location","precision":5}
+				},
+				"tiles":{"geotile_grid":{"field":"location","precision":8,"size":100}},
+				"viewport":{"geo_bounds":{"field":"location"}}
+			}
+		}`), query)
+
+		_, err = queryBuilder.New().Aggs(
+			queryBuilder.GeohashGridAgg(queryBuilder.GeoGridParams{Name: "cells", Field: "location", Precision: precision(13)}),
+		).Build(queryBuilder.ES)
+		assert.ErrorIs(t, err, queryBuilder.ErrInvalidValue)
+
+		query, err = queryBuilder.New().Aggs(
+			queryBuilder.GeotileGridAgg(queryBuilder.GeoGridParams{Name: "world", Field: "location", Precision: precision(0)}),
+		).Build(queryBuilder.ES)
+		assert.NoError(t, err)
+		assert.Equal(t, `{"aggs":{"world":{"geotile_grid":{"field":"location","precision":0}}}}`, query)
+
+		_, err = queryBuilder.New().Aggs(
+			queryBuilder.GeohashGridAgg(queryBuilder.GeoGridParams{Name: "cells", Field: "location", Precision: precision(0)}),
+		).Build(queryBuilder.ES)
+		assert.ErrorIs(t, err, queryBuilder.ErrInvalidValue)
+
+		_, err = queryBuilder.New().Aggs(
+			queryBuilder.GeotileGridAgg(queryBuilder.GeoGridParams{Name: "tiles", Field: "location"}),
+		).Build(queryBuilder.ES6)
+		assert.ErrorIs(t, err, queryBuilder.ErrUnsupportedQuery)
+	})
+
+	t.Run("geo_distance", func(t *testing.T) {
+		query, err := queryBuilder.New().Aggs(
+			queryBuilder.GeoDistanceAgg(queryBuilder.GeoDistanceAggParams{
+				Name:   "rings",
+				Field:  "location",
+				Origin: queryBuilder.LatLon(35.68, 139.69),
+				Unit:   queryBuilder.Kilometers,
+				Ranges: []queryBuilder.AggRange{{To: 1}, {From: 1, To: 5}, {From: 5}},
+			}),
+		).Build(queryBuilder.ES)
+
+		assert.NoError(t, err)
+		assert.Equal(t, queryBuilder.Trim(`{
+			"aggs":{
+				"rings":{
+					"geo_distance":{
+						"field":"location",
+						"origin":{"lat":35.68,"lon":139.69},
+						"ranges":[{"to":1},{"from":1,"to":5},{"from":5}],
+						"unit":"km"
+					}
+				}
+			}
+		}`), query)
+
+		_, err = queryBuilder.New().Aggs(
+			queryBuilder.GeoDistanceAgg(queryBuilder.GeoDistanceAggParams{Name: "rings", Field: "location"}),
+		).Build(queryBuilder.ES)
+		assert.ErrorIs(t, err, queryBuilder.ErrEmptyValues)
+	})
+}
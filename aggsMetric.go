@@ -0,0 +1,133 @@
+package queryBuilder
+
+type MetricParams struct {
+	Name    string
+	Field   string
+	Missing any
+	Script  *Script
+	Format  string
+}
+
+type MetricAggregation struct {
+	Type    string // avg, sum, min, ...
+	Params  MetricParams
+	Options map[string]any // type specific settings, e.g. precision_threshold
+}
+
+func (m *MetricAggregation) AggregationName() string {
+	return m.Params.Name
+}
+
+func (m *MetricAggregation) MarshalAggregation(dc DataSource) (any, error) {
+	if m.Params.Field == "" && m.Params.Script == nil {
+		return nil, &BuildError{m.call(), "params.Field", ErrEmptyField}
+	}
+
+	body := make(map[string]any, len(m.Options)+4)
+	for k, v := range m.Options {
+		body[k] = v
+	}
+	if m.Params.Field != "" {
+		body["field"] = m.Params.Field
+	}
+	if m.Params.Missing != nil {
+		body["missing"] = m.Params.Missing
+	}
+	if m.Params.Script != nil {
+		body["script"] = m.Params.Script
+	}
+	if m.Params.Format != "" {
+		body["format"] = m.Params.Format
+	}
+	return map[string]any{m.Type: body}, nil
+}
+
+var metricConstructors = map[string]string{
+	"avg":            "AvgAgg",
+	"sum":            "SumAgg",
+	"min":            "MinAgg",
+	"max":            "MaxAgg",
+	"value_count":    "ValueCountAgg",
+	"stats":          "StatsAgg",
+	"cardinality":    "CardinalityAgg",
+	"extended_stats": "ExtendedStatsAgg",
+	"percentiles":    "PercentilesAgg",
+}
+
+func (m *MetricAggregation) call() string {
+	if c, ok := metricConstructors[m.Type]; ok {
+		return c
+	}
+	return "MetricAggregation"
+}
+
+func metricAgg(kind string, params MetricParams, options map[string]any) *MetricAggregation {
+	return &MetricAggregation{Type: kind, Params: params, Options: options}
+}
+
+func AvgAgg(params MetricParams) *MetricAggregation {
+	return metricAgg("avg", params, nil)
+}
+
+func SumAgg(params MetricParams) *MetricAggregation {
+	return metricAgg("sum", params, nil)
+}
+
+func MinAgg(params MetricParams) *MetricAggregation {
+	return metricAgg("min", params, nil)
+}
+
+func MaxAgg(params MetricParams) *MetricAggregation {
+	return metricAgg("max", params, nil)
+}
+
+func ValueCountAgg(params MetricParams) *MetricAggregation {
+	return metricAgg("value_count", params, nil)
+}
+
+func StatsAgg(params MetricParams) *MetricAggregation {
+	return metricAgg("stats", params, nil)
+}
+
+type CardinalityParams struct {
+	MetricParams
+	PrecisionThreshold int
+}
+
+func CardinalityAgg(params CardinalityParams) *MetricAggregation {
+	options := map[string]any{}
+	if params.PrecisionThreshold != 0 {
+		options["precision_threshold"] = params.PrecisionThreshold
+	}
+	return metricAgg("cardinality", params.MetricParams, options)
+}
+
+type ExtendedStatsParams struct {
+	MetricParams
+	Sigma float64
+}
+
+func ExtendedStatsAgg(params ExtendedStatsParams) *MetricAggregation {
+	options := map[string]any{}
+	if params.Sigma != 0 {
+		options["sigma"] = params.Sigma
+	}
+	return metricAgg("extended_stats", params.MetricParams, options)
+}
+
+type PercentilesParams struct {
+	MetricParams
+	Percents []float64
+	Keyed    *bool // nil keeps the server default (keyed)
+}
+
+func PercentilesAgg(params PercentilesParams) *MetricAggregation {
+	options := map[string]any{}
+	if len(params.Percents) > 0 {
+		options["percents"] = params.Percents
+	}
+	if params.Keyed != nil {
+		options["keyed"] = *params.Keyed
+	}
+	return metricAgg("percentiles", params.MetricParams, options)
+}
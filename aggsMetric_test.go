@@ -0,0 +1,120 @@
+package queryBuilder_test
+
+import (
+	"testing"
+
+	"github.com/linksports/queryBuilder"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAggsMetric(t *testing.T) {
+	t.Run("simple metrics", func(t *testing.T) {
+		params := queryBuilder.MetricParams{Name: "m", Field: "score"}
+		for expected, agg := range map[string]queryBuilder.Aggregation{
+			`{"aggs":{"m":{"avg":{"field":"score"}}}}`:         queryBuilder.AvgAgg(params),
+			`{"aggs":{"m":{"sum":{"field":"score"}}}}`:         queryBuilder.SumAgg(params),
+			`{"aggs":{"m":{"min":{"field":"score"}}}}`:         queryBuilder.MinAgg(params),
+			`{"aggs":{"m":{"max":{"field":"score"}}}}`:         queryBuilder.MaxAgg(params),
+			`{"aggs":{"m":{"value_count":{"field":"score"}}}}`: queryBuilder.ValueCountAgg(params),
+			`{"aggs":{"m":{"stats":{"field":"score"}}}}`:       queryBuilder.StatsAgg(params),
+		} {
+			query, err := queryBuilder.New().Aggs(agg).Build(queryBuilder.ES)
+			assert.NoError(t, err)
+			assert.Equal(t, expected, query)
+		}
+	})
+
+	t.Run("missing+script+format", func(t *testing.T) {
+		query, err := queryBuilder.New().Aggs(
+			queryBuilder.AvgAgg(queryBuilder.MetricParams{
+				Name:    "avg_price",
+				Field:   "price",
+				Missing: 0,
+				Format:  "#,##0.00",
+				Script: &queryBuilder.Script{
+					Source: "_value * params.rate",
+					Params: map[string]any{"rate": 1.1},
+				},
+			}),
+		).Build(queryBuilder.ES)
+
+		assert.NoError(t, err)
+		assert.Equal(t, queryBuilder.Trim(`{
+			"aggs":{
+				"avg_price":{
+					"avg":{
+						"field":"price",
+						"format":"#,##0.00",
+						"missing":0,
+						"script":{"source":"_value * params.rate","params":{"rate":1.1}}
+					}
+				}
+			}
+		}`), query)
+	})
+
+	t.Run("type specific options", func(t *testing.T) {
+		keyed := false
+		query, err := queryBuilder.New().Aggs(
+			queryBuilder.CardinalityAgg(queryBuilder.CardinalityParams{
+				MetricParams:       queryBuilder.MetricParams{Name: "teams", Field: "team_id"},
+				PrecisionThreshold: 1000,
+			}),
+			queryBuilder.ExtendedStatsAgg(queryBuilder.ExtendedStatsParams{
+				MetricParams: queryBuilder.MetricParams{Name: "score_stats", Field: "score"},
+				Sigma:        3,
+			}),
+			queryBuilder.PercentilesAgg(queryBuilder.PercentilesParams{
+				MetricParams: queryBuilder.MetricParams{Name: "latency", Field: "took"},
+				Percents:     []float64{50, 95, 99.9},
+				Keyed:        &keyed,
+			}),
+		).Build(queryBuilder.ES)
+
+		assert.NoError(t, err)
+		assert.Equal(t, queryBuilder.Trim(`{
+			"aggs":{
+				"latency":{"percentiles":{"field":"took","keyed":false,"percents":[50,95,99.9]}},
+				"score_stats":{"extended_stats":{"field":"score","sigma":3}},
+				"teams":{"cardinality":{"field":"team_id","precision_threshold":1000}}
+			}
+		}`), query)
+	})
+
+	t.Run("as sub aggregation", func(t *testing.T) {
+		query, err := queryBuilder.New().Aggs(
+			queryBuilder.TermsAgg(queryBuilder.AggregateParams{
+				Name:      "teams",
+				FieldName: "team_id",
+				Order:     map[string]string{"avg_score": "desc"},
+			}).SubAggs(
+				queryBuilder.AvgAgg(queryBuilder.MetricParams{Name: "avg_score", Field: "score"}),
+				queryBuilder.MaxAgg(queryBuilder.MetricParams{Name: "max_score", Field: "score"}),
+			),
+		).Build(queryBuilder.ES)
+
+		assert.NoError(t, err)
+		assert.Equal(t, queryBuilder.Trim(`{
+			"aggs":{
+				"teams":{
+					"aggs":{
+						"avg_score":{"avg":{"field":"score"}},
+						"max_score":{"max":{"field":"score"}}
+					},
+					"terms":{"field":"team_id","order":{"avg_score":"desc"}}
+				}
+			}
+		}`), query)
+	})
+
+	t.Run("field or script is required", func(t *testing.T) {
+		_, err := queryBuilder.New().Aggs(
+			queryBuilder.ExtendedStatsAgg(queryBuilder.ExtendedStatsParams{
+				MetricParams: queryBuilder.MetricParams{Name: "s"},
+			}),
+		).Build(queryBuilder.ES)
+
+		assert.ErrorIs(t, err, queryBuilder.ErrEmptyField)
+		assert.EqualError(t, err, "Builder.Aggs(values[0]): ExtendedStatsAgg(params.Field): empty field name")
+	})
+}
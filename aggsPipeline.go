@@ -0,0 +1,262 @@
+package queryBuilder
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+type PipelineParams struct {
+	Name         string
+	BucketsPath  string            // derivative, cumulative_sum, moving_fn
+	BucketsPaths map[string]string // bucket_script, bucket_selector: script variable to path
+	Script       string            // bucket_script, bucket_selector, moving_fn
+	GapPolicy    string            // skip, insert_zeros or keep_values
+	Format       string
+	Window       int    // moving_fn
+	Shift        int    // moving_fn
+	Sort         []Sort // bucket_sort
+	From         int    // bucket_sort
+	Size         int    // bucket_sort
+}
+
+// PipelineAggregation computes values from the buckets of its sibling
+// aggregations. Build checks every buckets_path against those siblings.
+type PipelineAggregation struct {
+	Type   string // bucket_script, bucket_selector, bucket_sort, derivative, cumulative_sum or moving_fn
+	Params PipelineParams
+}
+
+var pipelineConstructors = map[string]string{
+	"bucket_script":   "BucketScriptAgg",
+	"bucket_selector": "BucketSelectorAgg",
+	"bucket_sort":     "BucketSortAgg",
+	"derivative":      "DerivativeAgg",
+	"cumulative_sum":  "CumulativeSumAgg",
+	"moving_fn":       "MovingFnAgg",
+}
+
+func (p *PipelineAggregation) call() string {
+	if c, ok := pipelineConstructors[p.Type]; ok {
+		return c
+	}
+	return "PipelineAggregation"
+}
+
+func (p *PipelineAggregation) AggregationName() string {
+	return p.Params.Name
+}
+
+func (p *PipelineAggregation) MarshalAggregation(dc DataSource) (any, error) {
+	params := p.Params
+	body := map[string]any{}
+
+	switch p.Type {
+	case "bucket_script", "bucket_selector":
+		if len(params.BucketsPaths) == 0 {
+			return nil, &BuildError{p.call(), "params.BucketsPaths", ErrEmptyValues}
+		}
+		if params.Script == "" {
+			return nil, &BuildError{p.call(), "params.Script", ErrEmptyValues}
+		}
+		body["buckets_path"] = params.BucketsPaths
+		body["script"] = params.Script
+	case "bucket_sort":
+		if params.From < 0 || params.Size < 0 {
+			return nil, &BuildError{p.call(), "params.From", ErrNegative}
+		}
+		if len(params.Sort) > 0 {
+			sorts := make([]any, len(params.Sort))
+			for i, s := range params.Sort {
+				clause, err := s.sortClause(dc)
+				if err != nil {
+					return nil, &BuildError{p.call(), fmt.Sprintf("params.Sort[%d]", i), err}
+				}
+				sorts[i] = clause
+			}
+			body["sort"] = sorts
+		}
+		setIf(body, "from", params.From, params.From != 0)
+		setIf(body, "size", params.Size, params.Size != 0)
+	case "derivative", "cumulative_sum", "moving_fn":
+		if params.BucketsPath == "" {
+			return nil, &BuildError{p.call(), "params.BucketsPath", ErrEmptyValues}
+		}
+		body["buckets_path"] = params.BucketsPath
+		if p.Type == "moving_fn" {
+			if params.Window <= 0 {
+				return nil, &BuildError{p.call(), "params.Window", fmt.Errorf("%w: window must be positive", ErrInvalidValue)}
+			}
+			if params.Script == "" {
+				return nil, &BuildError{p.call(), "params.Script", ErrEmptyValues}
+			}
+			if v, ok := dc.esVersion(); ok && !v.atLeast(6, 4) {
+				return nil, fmt.Errorf("%w: moving_fn requires Elasticsearch 6.4 or later, got %s", ErrUnsupportedQuery, dc)
+			}
+			body["window"] = params.Window
+			body["script"] = params.Script
+			setIf(body, "shift", params.Shift, params.Shift != 0)
+		}
+	default:
+		return nil, &BuildError{"PipelineAggregation", "Type", fmt.Errorf("%w: unknown pipeline %q", ErrInvalidValue, p.Type)}
+	}
+	setIf(body, "gap_policy", params.GapPolicy, params.GapPolicy != "")
+	setIf(body, "format", params.Format, params.Format != "")
+
+	return map[string]any{p.Type: body}, nil
+}
+
+// bucketsPaths lists the paths the rendered body refers to, keyed by the
+// argument they came from.
+func (p *PipelineAggregation) bucketsPaths() map[string]string {
+	paths := map[string]string{}
+	switch p.Type {
+	case "bucket_script", "bucket_selector":
+		for k, v := range p.Params.BucketsPaths {
+			paths[fmt.Sprintf("params.BucketsPaths[%q]", k)] = v
+		}
+	case "bucket_sort":
+		for i, s := range p.Params.Sort {
+			paths[fmt.Sprintf("params.Sort[%d].Field", i)] = s.Field
+		}
+	default:
+		paths["params.BucketsPath"] = p.Params.BucketsPath
+	}
+	return paths
+}
+
+func pipelineAgg(kind string, params PipelineParams) *PipelineAggregation {
+	return &PipelineAggregation{Type: kind, Params: params}
+}
+
+func BucketScriptAgg(params PipelineParams) *PipelineAggregation {
+	return pipelineAgg("bucket_script", params)
+}
+
+func BucketSelectorAgg(params PipelineParams) *PipelineAggregation {
+	return pipelineAgg("bucket_selector", params)
+}
+
+func BucketSortAgg(params PipelineParams) *PipelineAggregation {
+	return pipelineAgg("bucket_sort", params)
+}
+
+func DerivativeAgg(params PipelineParams) *PipelineAggregation {
+	return pipelineAgg("derivative", params)
+}
+
+func CumulativeSumAgg(params PipelineParams) *PipelineAggregation {
+	return pipelineAgg("cumulative_sum", params)
+}
+
+func MovingFnAgg(params PipelineParams) *PipelineAggregation {
+	return pipelineAgg("moving_fn", params)
+}
+
+// multiValueMetrics lists the values multi-value metrics expose to buckets_path.
+var multiValueMetrics = map[string][]string{
+	"stats":          {"count", "min", "max", "avg", "sum"},
+	"extended_stats": {"count", "min", "max", "avg", "sum", "sum_of_squares", "variance", "variance_population", "variance_sampling", "std_deviation", "std_deviation_population", "std_deviation_sampling", "std_upper", "std_lower", "std_upper_population", "std_lower_population", "std_upper_sampling", "std_lower_sampling"},
+}
+
+// checkBucketsPaths resolves the buckets_path of every pipeline aggregation
+// against its siblings, descending into sub-aggregations.
+func checkBucketsPaths(siblings []Aggregation) error {
+	var errs []error
+	for _, a := range siblings {
+		switch agg := a.(type) {
+		case *PipelineAggregation:
+			args := make([]string, 0)
+			paths := agg.bucketsPaths()
+			for arg := range paths {
+				args = append(args, arg)
+			}
+			sort.Strings(args)
+			for _, arg := range args {
+				if paths[arg] == "" {
+					continue
+				}
+				if err := resolveBucketsPath(siblings, paths[arg]); err != nil {
+					errs = append(errs, &BuildError{agg.call(), arg, fmt.Errorf("%w: %s: %w", ErrBucketsPath, agg.Params.Name, err)})
+				}
+			}
+		case parentAggregation:
+			errs = append(errs, checkBucketsPaths(agg.subAggregations()))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func findAgg(aggs []Aggregation, name string) Aggregation {
+	for _, a := range aggs {
+		if a != nil && a.AggregationName() == name {
+			return a
+		}
+	}
+	return nil
+}
+
+// resolveBucketsPath follows AGG_NAME[KEY]>AGG_NAME...[.METRIC] through aggs.
+func resolveBucketsPath(aggs []Aggregation, path string) error {
+	switch path {
+	case "_count", "_key":
+		return nil
+	}
+
+	segments := strings.Split(path, ">")
+	for i, segment := range segments {
+		last := i == len(segments)-1
+		if last && (segment == "_count" || segment == "_bucket_count") {
+			return nil
+		}
+
+		name, metric := segment, ""
+		if key := strings.IndexByte(name, '['); key >= 0 {
+			name = name[:key]
+		}
+		agg := findAgg(aggs, name)
+		if agg == nil && last {
+			if dot := strings.IndexByte(segment, '.'); dot >= 0 {
+				name, metric = segment[:dot], segment[dot+1:]
+				agg = findAgg(aggs, name)
+			}
+		}
+		if agg == nil {
+			return fmt.Errorf("no aggregation %q for path %q", name, path)
+		}
+
+		if last {
+			return checkMetric(agg, metric, path)
+		}
+		parent, ok := agg.(parentAggregation)
+		if !ok {
+			return fmt.Errorf("aggregation %q in path %q has no sub-aggregations", name, path)
+		}
+		aggs = parent.subAggregations()
+	}
+	return nil
+}
+
+func checkMetric(agg Aggregation, metric, path string) error {
+	m, ok := agg.(*MetricAggregation)
+	if !ok || metric == "" || metric == "_count" || metric == "_bucket_count" {
+		return nil
+	}
+
+	switch m.Type {
+	case "percentiles":
+		return nil
+	case "stats", "extended_stats":
+		for _, v := range multiValueMetrics[m.Type] {
+			if v == metric {
+				return nil
+			}
+		}
+		return fmt.Errorf("%s aggregation %q has no value %q for path %q", m.Type, m.Params.Name, metric, path)
+	}
+	if metric != "value" {
+		return fmt.Errorf("%s aggregation %q has no value %q for path %q", m.Type, m.Params.Name, metric, path)
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package queryBuilder_test
+
+import (
+	"testing"
+
+	"github.com/linksports/queryBuilder"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAggsPipeline(t *testing.T) {
+	perMonth := func(pipelines ...queryBuilder.Aggregation) *queryBuilder.Builder {
+		subAggs := []queryBuilder.Aggregation{
+			queryBuilder.SumAgg(queryBuilder.MetricParams{Name: "goals", Field: "goals"}),
+			queryBuilder.StatsAgg(queryBuilder.MetricParams{Name: "shots", Field: "shots"}),
+			queryBuilder.TermsAgg(queryBuilder.AggregateParams{Name: "teams", FieldName: "team"}).SubAggs(
+				queryBuilder.AvgAgg(queryBuilder.MetricParams{Name: "score", Field: "score"}),
+			),
+		}
+		return queryBuilder.New().Aggs(
+			queryBuilder.DateHistogramAgg(queryBuilder.DateHistogramParams{
+				Name:             "per_month",
+				Field:            "played_at",
+				CalendarInterval: "month",
+			}).SubAggs(append(subAggs, pipelines...)...),
+		)
+	}
+
+	t.Run("bucket_script+bucket_selector+bucket_sort", func(t *testing.T) {
+		query, err := perMonth(
+			queryBuilder.BucketScriptAgg(queryBuilder.PipelineParams{
+				Name:         "conversion",
+				BucketsPaths: map[string]string{"goals": "goals", "shots": "shots.sum"},
+				Script:       "params.goals / params.shots",
+				Format:       "0.00",
+			}),
+			queryBuilder.BucketSelectorAgg(queryBuilder.PipelineParams{
+				Name:         "active",
+				BucketsPaths: map[string]string{"count": "_count"},
+				Script:       "params.count > 10",
+			}),
+			queryBuilder.BucketSortAgg(queryBuilder.PipelineParams{
+				Name: "top",
+				Sort: []queryBuilder.Sort{{"goals", "desc"}},
+				Size: 3,
+			}),
+		).Build(queryBuilder.ES)
+
+		assert.NoError(t, err)
+		assert.Contains(t, query, `"conversion":{"bucket_script":{"buckets_path":{"goals":"goals","shots":"shots.sum"},"format":"0.00","script":"params.goals / params.shots"}}`)
+		assert.Contains(t, query, `"active":{"bucket_selector":{"buckets_path":{"count":"_count"},"script":"params.count > 10"}}`)
+		assert.Contains(t, query, `"top":{"bucket_sort":{"size":3,"sort":[{"goals":{"order":"desc"}}]}}`)
+	})
+
+	t.Run("derivative+cumulative_sum+moving_fn", func(t *testing.T) {
+		query, err := perMonth(
+			queryBuilder.DerivativeAgg(queryBuilder.PipelineParams{Name: "goals_diff", BucketsPath: "goals", GapPolicy: "insert_zeros"}),
+			queryBuilder.CumulativeSumAgg(queryBuilder.PipelineParams{Name: "goals_total", BucketsPath: "goals"}),
+			queryBuilder.MovingFnAgg(queryBuilder.PipelineParams{
+				Name:        "goals_trend",
+				BucketsPath: "goals_total",
+				Window:      3,
+				Script:      "MovingFunctions.unweightedAvg(values)",
+			}),
+		).Build(queryBuilder.ES)
+
+		assert.NoError(t, err)
+		assert.Contains(t, query, `"goals_diff":{"derivative":{"buckets_path":"goals","gap_policy":"insert_zeros"}}`)
+		assert.Contains(t, query, `"goals_total":{"cumulative_sum":{"buckets_path":"goals"}}`)
+		assert.Contains(t, query, `"goals_trend":{"moving_fn":{"buckets_path":"goals_total","script":"MovingFunctions.unweightedAvg(values)","window":3}}`)
+
+		_, err = perMonth(
+			queryBuilder.MovingFnAgg(queryBuilder.PipelineParams{Name: "trend", BucketsPath: "goals", Script: "x"}),
+		).Build(queryBuilder.ES)
+		assert.ErrorIs(t, err, queryBuilder.ErrInvalidValue)
+	})
+
+	t.Run("buckets_path", func(t *testing.T) {
+		for _, path := range []string{"goals", "goals.value", "shots.avg", "_count", "teams>score", "teams._bucket_count", "teams['a']>_count"} {
+			_, err := perMonth(
+				queryBuilder.DerivativeAgg(queryBuilder.PipelineParams{Name: "d", BucketsPath: path}),
+			).Build(queryBuilder.ES)
+			assert.NoError(t, err, path)
+		}
+
+		for _, path := range []string{"goal", "shots.median", "goals.avg", "goals>score", "teams>scores", "teams>score.max"} {
+			_, err := perMonth(
+				queryBuilder.DerivativeAgg(queryBuilder.PipelineParams{Name: "d", BucketsPath: path}),
+			).Build(queryBuilder.ES)
+			assert.ErrorIs(t, err, queryBuilder.ErrBucketsPath, path)
+		}
+
+		_, err := perMonth(
+			queryBuilder.BucketScriptAgg(queryBuilder.PipelineParams{
+				Name:         "ratio",
+				BucketsPaths: map[string]string{"a": "goals", "b": "shot.sum"},
+				Script:       "params.a / params.b",
+			}),
+		).Build(queryBuilder.ES)
+		assert.ErrorIs(t, err, queryBuilder.ErrBucketsPath)
+		assert.ErrorContains(t, err, `BucketScriptAgg(params.BucketsPaths["b"])`)
+
+		_, err = perMonth(
+			queryBuilder.BucketScriptAgg(queryBuilder.PipelineParams{
+				Name:         "ratio",
+				BucketsPath:  "goals",
+				BucketsPaths: map[string]string{"a": "goalz"},
+				Script:       "params.a",
+			}),
+		).Build(queryBuilder.ES)
+		assert.ErrorIs(t, err, queryBuilder.ErrBucketsPath)
+
+		_, err = perMonth(
+			queryBuilder.BucketSortAgg(queryBuilder.PipelineParams{
+				Name: "top",
+				Sort: []queryBuilder.Sort{{"_key", queryBuilder.Asc}, {"shots.avg", queryBuilder.Desc}},
+			}),
+		).Build(queryBuilder.ES)
+		assert.NoError(t, err)
+
+		_, err = perMonth(
+			queryBuilder.BucketSortAgg(queryBuilder.PipelineParams{
+				Name: "top",
+				Sort: []queryBuilder.Sort{{"typo2", queryBuilder.Desc}},
+			}),
+		).Build(queryBuilder.ES)
+		assert.ErrorIs(t, err, queryBuilder.ErrBucketsPath)
+		assert.ErrorContains(t, err, "BucketSortAgg(params.Sort[0].Field)")
+	})
+}
@@ -0,0 +1,248 @@
+package queryBuilder_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/linksports/queryBuilder"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAggs(t *testing.T) {
+	t.Run("aggregations and queries are distinct", func(t *testing.T) {
+		var agg any = queryBuilder.TermsAgg(queryBuilder.AggregateParams{Name: "a", FieldName: "a"})
+		_, isQuery := agg.(queryBuilder.Query)
+		assert.False(t, isQuery)
+
+		var query any = queryBuilder.Match("name", "tokyo")
+		_, isAgg := query.(queryBuilder.Aggregation)
+		assert.False(t, isAgg)
+	})
+
+	t.Run("raw", func(t *testing.T) {
+		query, err := queryBuilder.New().Aggs(
+			queryBuilder.RawAgg("avg_score", json.RawMessage(`{"avg": {"field": "score"}}`)),
+			queryBuilder.TermsAgg(queryBuilder.AggregateParams{Name: "team", FieldName: "team_id"}),
+		).Build(queryBuilder.ES)
+
+		assert.NoError(t, err)
+		assert.Equal(t, queryBuilder.Trim(`{
+			"aggs":{
+				"avg_score":{"avg":{"field":"score"}},
+				"team":{"terms":{"field":"team_id"}}
+			}
+		}`), query)
+
+		_, err = queryBuilder.New().Aggs(queryBuilder.RawAgg("broken", json.RawMessage(`{"avg":`))).Build(queryBuilder.ES)
+		assert.Error(t, err)
+	})
+
+	t.Run("sub aggregations", func(t *testing.T) {
+		query, err := queryBuilder.New().Aggs(
+			queryBuilder.TermsAgg(queryBuilder.AggregateParams{
+				Name:      "leagues",
+				FieldName: "league_id",
+			}).SubAggs(
+				queryBuilder.TermsAgg(queryBuilder.AggregateParams{
+					Name:      "top_teams",
+					FieldName: "team_id",
+					Order:     map[string]string{"avg_score": "desc"},
+					Size:      3,
+				}).SubAggs(
+					queryBuilder.RawAgg("avg_score", json.RawMessage(`{"avg":{"field":"score"}}`)),
+				),
+			),
+		).Size(0).Build(queryBuilder.ES)
+
+		assert.NoError(t, err)
+		assert.Equal(t, queryBuilder.Trim(`{
+			"aggs":{
+				"leagues":{
+					"aggs":{
+						"top_teams":{
+							"aggs":{
+								"avg_score":{"avg":{"field":"score"}}
+							},
+							"terms":{
+								"field":"team_id",
+								"order":{"avg_score":"desc"},
+								"size":3
+							}
+						}
+					},
+					"terms":{"field":"league_id"}
+				}
+			}
+		}`), query)
+	})
+
+	t.Run("sub aggregation errors", func(t *testing.T) {
+		builder := queryBuilder.New().Aggs(
+			queryBuilder.TermsAgg(queryBuilder.AggregateParams{Name: "leagues", FieldName: "league_id"}).SubAggs(
+				queryBuilder.TermsAgg(queryBuilder.AggregateParams{Name: "teams", FieldName: "team_id", Order: map[string]string{"_term": "asc"}}),
+				queryBuilder.TermsAgg(queryBuilder.AggregateParams{Name: "teams", FieldName: "team_name"}),
+			),
+		)
+
+		_, err := builder.Build(queryBuilder.ES)
+		assert.ErrorIs(t, err, queryBuilder.ErrDuplicateName)
+		assert.EqualError(t, err, `Builder.Aggs(values[0]): TermsAggregation.SubAggs(values[1]): duplicate name: "teams"`)
+
+		_, warnings, err := queryBuilder.New().Aggs(
+			queryBuilder.TermsAgg(queryBuilder.AggregateParams{Name: "leagues", FieldName: "league_id"}).SubAggs(
+				queryBuilder.TermsAgg(queryBuilder.AggregateParams{Name: "teams", FieldName: "team_id", Order: map[string]string{"_term": "asc"}}),
+			),
+		).BuildWithWarnings(queryBuilder.ES)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"aggs.teams: ordering by _term is deprecated since Elasticsearch 6.0, rendered as _key"}, warnings)
+	})
+}
+
+func TestTermsAggOptions(t *testing.T) {
+	zero := 0
+
+	t.Run("options", func(t *testing.T) {
+		query, err := queryBuilder.New().Aggs(
+			queryBuilder.TermsAgg(queryBuilder.AggregateParams{
+				Name:                  "teams",
+				FieldName:             "team_id",
+				OrderBy:               []queryBuilder.TermsOrder{{"avg_score", "desc"}, {"_term", "asc"}},
+				Size:                  10,
+				ShardSize:             50,
+				MinDocCount:           &zero,
+				Missing:               "N/A",
+				Include:               &queryBuilder.TermsFilter{Regex: "tokyo.*"},
+				Exclude:               &queryBuilder.TermsFilter{Values: []any{"tokyo-test"}},
+				ExecutionHint:         "map",
+				ShowTermDocCountError: true,
+			}).SubAggs(
+				queryBuilder.AvgAgg(queryBuilder.MetricParams{Name: "avg_score", Field: "score"}),
+			),
+		).Build(queryBuilder.ES)
+
+		assert.NoError(t, err)
+		assert.Equal(t, queryBuilder.Trim(`{
+			"aggs":{
+				"teams":{
+					"aggs":{
+						"avg_score":{"avg":{"field":"score"}}
+					},
+					"terms":{
+						"exclude":["tokyo-test"],
+						"execution_hint":"map",
+						"field":"team_id",
+						"include":"tokyo.*",
+						"min_doc_count":0,
+						"missing":"N/A",
+						"order":[{"avg_score":"desc"},{"_key":"asc"}],
+						"shard_size":50,
+						"show_term_doc_count_error":true,
+						"size":10
+					}
+				}
+			}
+		}`), query)
+	})
+
+	t.Run("partition", func(t *testing.T) {
+		query, err := queryBuilder.New().Aggs(
+			queryBuilder.TermsAgg(queryBuilder.AggregateParams{
+				Name:      "users",
+				FieldName: "user_id",
+				Include:   &queryBuilder.TermsFilter{Partition: 2, NumPartitions: 20},
+			}),
+		).Build(queryBuilder.ES)
+
+		assert.NoError(t, err)
+		assert.Equal(t, `{"aggs":{"users":{"terms":{"field":"user_id","include":{"num_partitions":20,"partition":2}}}}}`, query)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		for _, params := range []queryBuilder.AggregateParams{
+			{Name: "a", FieldName: "a", Exclude: &queryBuilder.TermsFilter{Partition: 0, NumPartitions: 2}},
+			{Name: "a", FieldName: "a", Include: &queryBuilder.TermsFilter{Partition: 2, NumPartitions: 2}},
+			{Name: "a", FieldName: "a", Include: &queryBuilder.TermsFilter{Regex: "a.*", Values: []any{"b"}}},
+			{Name: "a", FieldName: "a", OrderBy: []queryBuilder.TermsOrder{{"_count", "up"}}},
+			{Name: "a", FieldName: "a", Order: map[string]string{"_count": "asc"}, OrderBy: []queryBuilder.TermsOrder{{"_key", "asc"}}},
+		} {
+			_, err := queryBuilder.New().Aggs(queryBuilder.TermsAgg(params)).Build(queryBuilder.ES)
+			assert.ErrorIs(t, err, queryBuilder.ErrInvalidValue)
+		}
+	})
+
+	t.Run("multi_terms", func(t *testing.T) {
+		agg := queryBuilder.MultiTermsAgg(queryBuilder.MultiTermsParams{
+			Name: "league_team",
+			Terms: []queryBuilder.MultiTermsField{
+				{Field: "league_id"},
+				{Field: "team_id", Missing: 0},
+			},
+			Order: []queryBuilder.TermsOrder{{"_count", "desc"}},
+			Size:  5,
+		})
+		query, err := queryBuilder.New().Aggs(agg).Build(queryBuilder.ES8)
+
+		assert.NoError(t, err)
+		assert.Equal(t, queryBuilder.Trim(`{
+			"aggs":{
+				"league_team":{
+					"multi_terms":{
+						"order":[{"_count":"desc"}],
+						"size":5,
+						"terms":[{"field":"league_id"},{"field":"team_id","missing":0}]
+					}
+				}
+			}
+		}`), query)
+
+		_, err = queryBuilder.New().Aggs(agg).Build(queryBuilder.ESVersion("7.10"))
+		assert.ErrorIs(t, err, queryBuilder.ErrUnsupportedQuery)
+
+		_, err = queryBuilder.New().Aggs(
+			queryBuilder.MultiTermsAgg(queryBuilder.MultiTermsParams{Name: "one", Terms: []queryBuilder.MultiTermsField{{Field: "a"}}}),
+		).Build(queryBuilder.ES8)
+		assert.ErrorIs(t, err, queryBuilder.ErrEmptyValues)
+	})
+}
+
+func TestBuilderAggs(t *testing.T) {
+	teams := queryBuilder.TermsAgg(queryBuilder.AggregateParams{Name: "teams", FieldName: "team_id"})
+	leagues := queryBuilder.TermsAgg(queryBuilder.AggregateParams{Name: "leagues", FieldName: "league_id"})
+	score := queryBuilder.AvgAgg(queryBuilder.MetricParams{Name: "score", Field: "score"})
+
+	t.Run("accumulate", func(t *testing.T) {
+		query, err := queryBuilder.New().Aggs(teams).Aggs(leagues, score).Build(queryBuilder.ES)
+
+		assert.NoError(t, err)
+		assert.Equal(t, queryBuilder.Trim(`{
+			"aggs":{
+				"leagues":{"terms":{"field":"league_id"}},
+				"score":{"avg":{"field":"score"}},
+				"teams":{"terms":{"field":"team_id"}}
+			}
+		}`), query)
+	})
+
+	t.Run("duplicate across calls", func(t *testing.T) {
+		_, err := queryBuilder.New().Aggs(teams, leagues).Aggs(teams).Build(queryBuilder.ES)
+		assert.ErrorIs(t, err, queryBuilder.ErrDuplicateName)
+		assert.EqualError(t, err, `Builder.Aggs(values[2]): duplicate name: "teams"`)
+	})
+
+	t.Run("remove+clear", func(t *testing.T) {
+		builder := queryBuilder.New().Aggs(teams, leagues, score).RemoveAgg("leagues").RemoveAgg("unknown")
+		query, err := builder.Build(queryBuilder.ES)
+		assert.NoError(t, err)
+		assert.Equal(t, `{"aggs":{"score":{"avg":{"field":"score"}},"teams":{"terms":{"field":"team_id"}}}}`, query)
+
+		query, err = builder.RemoveAgg("teams").Aggs(
+			queryBuilder.TermsAgg(queryBuilder.AggregateParams{Name: "teams", FieldName: "team_name"}),
+		).Build(queryBuilder.ES)
+		assert.NoError(t, err)
+		assert.Equal(t, `{"aggs":{"score":{"avg":{"field":"score"}},"teams":{"terms":{"field":"team_name"}}}}`, query)
+
+		query, err = builder.ClearAggs().Size(10).Build(queryBuilder.ES)
+		assert.NoError(t, err)
+		assert.Equal(t, `{"size":10}`, query)
+	})
+}
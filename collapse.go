@@ -0,0 +1,108 @@
+package queryBuilder
+
+import (
+	"errors"
+	"fmt"
+)
+
+type InnerHits struct {
+	Name   string // required when there are several
+	Size   int
+	From   int
+	Sort   []Sorter
+	Source []string
+}
+
+type CollapseParams struct {
+	InnerHits                  []InnerHits
+	MaxConcurrentGroupSearches int
+}
+
+type collapse struct {
+	field  string
+	params CollapseParams
+}
+
+// Collapse keeps the top hit for each value of a keyword or numeric field.
+func (b *Builder) Collapse(field string, params CollapseParams) *Builder {
+	b.collapse = &collapse{field, params}
+	return b
+}
+
+func (h InnerHits) generate(dc DataSource, arg string) (map[string]any, error) {
+	if h.Size < 0 || h.From < 0 {
+		return nil, &BuildError{"Builder.Collapse", arg + ".Size", ErrNegative}
+	}
+
+	body := map[string]any{}
+	setIf(body, "name", h.Name, h.Name != "")
+	setIf(body, "size", h.Size, h.Size != 0)
+	setIf(body, "from", h.From, h.From != 0)
+	if len(h.Sort) > 0 {
+		sort := make([]any, len(h.Sort))
+		for i, s := range h.Sort {
+			if s == nil {
+				return nil, &BuildError{"Builder.Collapse", fmt.Sprintf("%s.Sort[%d]", arg, i), fmt.Errorf("%w: nil sort", ErrWrongNodeKind)}
+			}
+			clause, err := s.sortClause(dc)
+			if err != nil {
+				return nil, &BuildError{"Builder.Collapse", fmt.Sprintf("%s.Sort[%d]", arg, i), err}
+			}
+			sort[i] = clause
+		}
+		body["sort"] = sort
+	}
+	setIf(body, "_source", h.Source, len(h.Source) > 0)
+	return body, nil
+}
+
+// generateCollapse also rejects the combinations Elasticsearch refuses:
+// rescore, and search_after unless the only sort is on the collapse field.
+func (b *Builder) generateCollapse(dc DataSource) (any, error) {
+	if b.collapse == nil {
+		return nil, nil
+	}
+	c := b.collapse
+	if err := requireField("Builder.Collapse", c.field); err != nil {
+		return nil, err
+	}
+	if c.params.MaxConcurrentGroupSearches < 0 {
+		return nil, &BuildError{"Builder.Collapse", "params.MaxConcurrentGroupSearches", ErrNegative}
+	}
+	if len(b.rescore) > 0 {
+		return nil, &BuildError{"Builder.Collapse", "field", fmt.Errorf("%w: collapse cannot be combined with rescore", ErrInvalidValue)}
+	}
+	if len(b.searchAfter) > 0 && (len(b.sort) != 1 || sortField(b.sort[0]) != c.field) {
+		return nil, &BuildError{"Builder.Collapse", "field", fmt.Errorf("%w: search_after with collapse needs a single sort on %q", ErrInvalidValue, c.field)}
+	}
+
+	body := map[string]any{"field": c.field}
+	setIf(body, "max_concurrent_group_searches", c.params.MaxConcurrentGroupSearches, c.params.MaxConcurrentGroupSearches != 0)
+	if len(c.params.InnerHits) > 0 {
+		var errs []error
+		names := map[string]bool{}
+		innerHits := make([]any, len(c.params.InnerHits))
+		for i, h := range c.params.InnerHits {
+			arg := fmt.Sprintf("params.InnerHits[%d]", i)
+			if len(c.params.InnerHits) > 1 && h.Name == "" {
+				errs = append(errs, &BuildError{"Builder.Collapse", arg + ".Name", ErrEmptyName})
+			}
+			if h.Name != "" && names[h.Name] {
+				errs = append(errs, &BuildError{"Builder.Collapse", arg + ".Name", fmt.Errorf("%w: %q", ErrDuplicateName, h.Name)})
+			}
+			names[h.Name] = true
+			hits, err := h.generate(dc, arg)
+			errs = append(errs, err)
+			innerHits[i] = hits
+		}
+		if err := errors.Join(errs...); err != nil {
+			return nil, err
+		}
+		if len(innerHits) == 1 {
+			body["inner_hits"] = innerHits[0]
+		} else {
+			body["inner_hits"] = innerHits
+		}
+	}
+	return body, nil
+}
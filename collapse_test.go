@@ -0,0 +1,84 @@
+package queryBuilder_test
+
+import (
+	"testing"
+
+	"github.com/linksports/queryBuilder"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCollapse(t *testing.T) {
+	t.Run("inner_hits", func(t *testing.T) {
+		query, err := queryBuilder.New().Query(
+			queryBuilder.Match("name", "tokyo"),
+		).Collapse("team_id", queryBuilder.CollapseParams{
+			InnerHits: []queryBuilder.InnerHits{{
+				Name:   "latest",
+				Size:   3,
+				Sort:   []queryBuilder.Sorter{queryBuilder.Sort{"played_at", queryBuilder.Desc}},
+				Source: []string{"title"},
+			}},
+			MaxConcurrentGroupSearches: 4,
+		}).Build(queryBuilder.ES)
+
+		assert.NoError(t, err)
+		assert.Equal(t, queryBuilder.Trim(`{
+			"query":{"match":{"name":"tokyo"}},
+			"collapse":{
+				"field":"team_id",
+				"inner_hits":{"_source":["title"],"name":"latest","size":3,"sort":[{"played_at":{"order":"desc"}}]},
+				"max_concurrent_group_searches":4
+			}
+		}`), query)
+
+		query, err = queryBuilder.New().Collapse("team_id", queryBuilder.CollapseParams{
+			InnerHits: []queryBuilder.InnerHits{{Name: "a", Size: 1}, {Name: "b", From: 1}},
+		}).Build(queryBuilder.ES)
+		assert.NoError(t, err)
+		assert.Equal(t, `{"collapse":{"field":"team_id","inner_hits":[{"name":"a","size":1},{"from":1,"name":"b"}]}}`, query)
+
+		_, err = queryBuilder.New().Collapse("team_id", queryBuilder.CollapseParams{
+			InnerHits: []queryBuilder.InnerHits{{Name: "a"}, {Name: "a"}, {}},
+		}).Build(queryBuilder.ES)
+		assert.ErrorIs(t, err, queryBuilder.ErrDuplicateName)
+		assert.ErrorIs(t, err, queryBuilder.ErrEmptyName)
+	})
+
+	t.Run("search_after", func(t *testing.T) {
+		query, err := queryBuilder.New().Collapse("team_id", queryBuilder.CollapseParams{}).
+			Sort(queryBuilder.Sort{"team_id", queryBuilder.Asc}).
+			SearchAfter(42).
+			Build(queryBuilder.ES)
+		assert.NoError(t, err)
+		assert.Equal(t, `{"sort":[{"team_id":{"order":"asc"}}],"search_after":[42],"collapse":{"field":"team_id"}}`, query)
+
+		_, err = queryBuilder.New().Collapse("team_id", queryBuilder.CollapseParams{}).
+			Sort(queryBuilder.Sort{"played_at", queryBuilder.Desc}).
+			SearchAfter(1700000000000).
+			Build(queryBuilder.ES)
+		assert.ErrorIs(t, err, queryBuilder.ErrInvalidValue)
+	})
+
+	t.Run("rescore", func(t *testing.T) {
+		weight := 0.7
+		builder := queryBuilder.New().Query(queryBuilder.Match("name", "tokyo")).Rescore(queryBuilder.Rescore{
+			WindowSize:         50,
+			Query:              queryBuilder.MatchPhrase("name", []string{"tokyo", "fc"}),
+			RescoreQueryWeight: &weight,
+		})
+
+		query, err := builder.Build(queryBuilder.ES)
+		assert.NoError(t, err)
+		assert.Equal(t, queryBuilder.Trim(`{
+			"query":{"match":{"name":"tokyo"}},
+			"rescore":[{
+				"query":{"rescore_query":{"match_phrase":{"name":"tokyo fc"}},"rescore_query_weight":0.7},
+				"window_size":50
+			}]
+		}`), query)
+
+		_, err = builder.Collapse("team_id", queryBuilder.CollapseParams{}).Build(queryBuilder.ES)
+		assert.ErrorIs(t, err, queryBuilder.ErrInvalidValue)
+		assert.ErrorContains(t, err, "rescore")
+	})
+}
@@ -0,0 +1,96 @@
+package queryBuilder
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+type cursorPayload struct {
+	Sort  json.RawMessage `json:"sort"`
+	After []any           `json:"after"`
+}
+
+// sortSpec renders the sort section a cursor is bound to.
+func (b *Builder) sortSpec(dc DataSource, call string) (json.RawMessage, error) {
+	if len(b.sort) == 0 {
+		return nil, &BuildError{call, "sort", fmt.Errorf("%w: search_after needs a sort", ErrEmptyValues)}
+	}
+	sort, err := b.generateSort(dc)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(sort)
+}
+
+func signCursor(key, payload []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+// Cursor returns an opaque token for the page after the hit with the given
+// sort values. The token carries the sort section and is signed with key, so
+// AfterCursor rejects tokens that were altered or made for another sort.
+func (b *Builder) Cursor(dc DataSource, key []byte, sortValues []any) (string, error) {
+	if len(key) == 0 {
+		return "", &BuildError{"Builder.Cursor", "key", ErrEmptyValues}
+	}
+	spec, err := b.sortSpec(dc, "Builder.Cursor")
+	if err != nil {
+		return "", err
+	}
+	if len(sortValues) != len(b.sort) {
+		return "", &BuildError{"Builder.Cursor", "sortValues", fmt.Errorf("%w: %d values for %d sort clauses", ErrInvalidValue, len(sortValues), len(b.sort))}
+	}
+
+	payload, err := json.Marshal(cursorPayload{spec, sortValues})
+	if err != nil {
+		return "", err
+	}
+	enc := base64.RawURLEncoding
+	return enc.EncodeToString(payload) + "." + enc.EncodeToString(signCursor(key, payload)), nil
+}
+
+// AfterCursor returns a copy of b that searches after the hit a Cursor token
+// points to.
+func (b *Builder) AfterCursor(dc DataSource, key []byte, cursor string) (*Builder, error) {
+	invalid := func(reason string) error {
+		return &BuildError{"Builder.AfterCursor", "cursor", fmt.Errorf("%w: %s", ErrInvalidCursor, reason)}
+	}
+	if len(key) == 0 {
+		return nil, &BuildError{"Builder.AfterCursor", "key", ErrEmptyValues}
+	}
+
+	enc := base64.RawURLEncoding
+	data, sig, ok := strings.Cut(cursor, ".")
+	if !ok {
+		return nil, invalid("malformed")
+	}
+	payload, err := enc.DecodeString(data)
+	if err != nil {
+		return nil, invalid("malformed")
+	}
+	mac, err := enc.DecodeString(sig)
+	if err != nil || !hmac.Equal(mac, signCursor(key, payload)) {
+		return nil, invalid("bad signature")
+	}
+
+	var p cursorPayload
+	if err := unmarshal(payload, &p); err != nil {
+		return nil, invalid("malformed")
+	}
+	spec, err := b.sortSpec(dc, "Builder.AfterCursor")
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(p.Sort, spec) {
+		return nil, invalid("made for a different sort")
+	}
+
+	return b.Clone().SearchAfter(p.After...), nil
+}
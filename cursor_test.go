@@ -0,0 +1,83 @@
+package queryBuilder_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/linksports/queryBuilder"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCursor(t *testing.T) {
+	key := []byte("secret")
+	builder := queryBuilder.New().Query(queryBuilder.MatchAll()).Sort(
+		queryBuilder.Sort{"played_at", queryBuilder.Desc},
+		queryBuilder.Sort{"id", queryBuilder.Asc},
+	).Size(10)
+
+	// sort values as decoded from the last hit of a response
+	var hit struct {
+		Sort []any `json:"sort"`
+	}
+	d := json.NewDecoder(bytes.NewReader([]byte(`{"sort":[1700000000001,"team-9"]}`)))
+	d.UseNumber()
+	assert.NoError(t, d.Decode(&hit))
+
+	t.Run("round trip", func(t *testing.T) {
+		cursor, err := builder.Cursor(queryBuilder.ES, key, hit.Sort)
+		assert.NoError(t, err)
+		assert.NotContains(t, cursor, "team-9")
+
+		next, err := builder.AfterCursor(queryBuilder.ES, key, cursor)
+		assert.NoError(t, err)
+
+		query, err := next.Build(queryBuilder.ES)
+		assert.NoError(t, err)
+		assert.Equal(t, queryBuilder.Trim(`{
+			"size":10,
+			"query":{"match_all":{}},
+			"sort":[{"played_at":{"order":"desc"}},{"id":{"order":"asc"}}],
+			"search_after":[1700000000001,"team-9"]
+		}`), query)
+
+		query, err = builder.Build(queryBuilder.ES)
+		assert.NoError(t, err)
+		assert.NotContains(t, query, "search_after")
+	})
+
+	t.Run("rejected", func(t *testing.T) {
+		cursor, err := builder.Cursor(queryBuilder.ES, key, hit.Sort)
+		assert.NoError(t, err)
+		data, sig, _ := strings.Cut(cursor, ".")
+
+		for name, c := range map[string]string{
+			"tampered payload": data[:len(data)-2] + "AA." + sig,
+			"tampered mac":     data + "." + sig[:len(sig)-2] + "AA",
+			"malformed":        "not-a-cursor",
+		} {
+			_, err := builder.AfterCursor(queryBuilder.ES, key, c)
+			assert.ErrorIs(t, err, queryBuilder.ErrInvalidCursor, name)
+		}
+
+		_, err = builder.AfterCursor(queryBuilder.ES, []byte("other"), cursor)
+		assert.ErrorIs(t, err, queryBuilder.ErrInvalidCursor)
+
+		other := queryBuilder.New().Sort(queryBuilder.Sort{"played_at", queryBuilder.Asc}, queryBuilder.Sort{"id", queryBuilder.Asc})
+		_, err = other.AfterCursor(queryBuilder.ES, key, cursor)
+		assert.ErrorIs(t, err, queryBuilder.ErrInvalidCursor)
+		assert.ErrorContains(t, err, "different sort")
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		_, err := builder.Cursor(queryBuilder.ES, key, hit.Sort[:1])
+		assert.ErrorIs(t, err, queryBuilder.ErrInvalidValue)
+
+		_, err = builder.Cursor(queryBuilder.ES, nil, hit.Sort)
+		assert.ErrorIs(t, err, queryBuilder.ErrEmptyValues)
+
+		_, err = queryBuilder.New().Cursor(queryBuilder.ES, key, hit.Sort)
+		assert.ErrorIs(t, err, queryBuilder.ErrEmptyValues)
+	})
+}
@@ -0,0 +1,284 @@
+package queryBuilder
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	ES6 = ES + "/6"
+	ES7 = ES + "/7"
+	ES8 = ES + "/8"
+)
+
+// ESVersion targets a specific Elasticsearch release, e.g. ESVersion("8.14").
+// The plain ES data source is rendered as 7.x.
+func ESVersion(version string) DataSource {
+	return ES + "/" + DataSource(version)
+}
+
+func (dc DataSource) Dialect() DataSource {
+	dialect, _, _ := strings.Cut(string(dc), "/")
+	return DataSource(dialect)
+}
+
+func (dc DataSource) Version() string {
+	_, version, _ := strings.Cut(string(dc), "/")
+	return version
+}
+
+type version struct {
+	major int
+	minor int
+}
+
+func (v version) atLeast(major, minor int) bool {
+	return v.major > major || v.major == major && v.minor >= minor
+}
+
+func (v version) String() string {
+	return fmt.Sprintf("%d.%d", v.major, v.minor)
+}
+
+// esVersion reports the targeted Elasticsearch version; ok is false for other dialects.
+func (dc DataSource) esVersion() (v version, ok bool) {
+	if dc.Dialect() != ES {
+		return version{}, false
+	}
+	if dc.Version() == "" {
+		return version{7, 17}, true
+	}
+
+	parts := strings.SplitN(dc.Version(), ".", 3)
+	major, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return version{}, false
+	}
+	minor := 0
+	if len(parts) > 1 {
+		if minor, err = strconv.Atoi(parts[1]); err != nil {
+			return version{}, false
+		}
+	}
+	return version{major, minor}, true
+}
+
+// requireES fails unless dc is Elasticsearch at or above major.minor.
+func requireES(dc DataSource, name string, major, minor int) error {
+	if v, ok := dc.esVersion(); !ok || !v.atLeast(major, minor) {
+		return fmt.Errorf("%w: %s requires Elasticsearch %d.%d or later, got %s", ErrUnsupportedQuery, name, major, minor, dc)
+	}
+	return nil
+}
+
+// deprecatedSyntax is implemented by nodes that render differently on versions
+// which removed part of their syntax.
+type deprecatedSyntax interface {
+	deprecations(dc DataSource) []string
+}
+
+// deprecations lists the warnings of every node of the query, post_filter
+// and aggregation trees.
+func (b *Builder) deprecations(dc DataSource) []string {
+	var warnings []string
+	postFilter, facetAggs := b.facetFilters()
+	for _, q := range []Query{b.query, postFilter} {
+		Inspect(q, func(n Node) bool {
+			if d, ok := n.(deprecatedSyntax); ok {
+				warnings = append(warnings, d.deprecations(dc)...)
+			}
+			return true
+		})
+	}
+	walkAggs(append(slices.Clone(b.aggs), facetAggs...), func(a Aggregation) {
+		if d, ok := a.(deprecatedSyntax); ok {
+			warnings = append(warnings, d.deprecations(dc)...)
+		}
+	})
+	return warnings
+}
+
+type KnnSearchParams struct {
+	Field         string
+	QueryVector   []float32
+	K             int
+	NumCandidates int
+	Similarity    float32
+	Boost         float32
+	Filter        Query
+}
+
+func (k KnnSearchParams) generate(dc DataSource) (any, error) {
+	if k.Field == "" {
+		return nil, &BuildError{"Builder.Knn", "params.Field", ErrEmptyField}
+	}
+	var filter any
+	if k.Filter != nil {
+		f, err := render(k.Filter, dc, "Builder.Knn", "params.Filter")
+		if err != nil {
+			return nil, err
+		}
+		filter = f
+	}
+
+	return struct {
+		Field         string    `json:"field"`
+		QueryVector   []float32 `json:"query_vector"`
+		K             int       `json:"k,omitempty"`
+		NumCandidates int       `json:"num_candidates,omitempty"`
+		Similarity    float32   `json:"similarity,omitempty"`
+		Boost         float32   `json:"boost,omitempty"`
+		Filter        any       `json:"filter,omitempty"`
+	}{
+		k.Field,
+		k.QueryVector,
+		k.K,
+		k.NumCandidates,
+		k.Similarity,
+		k.Boost,
+		filter,
+	}, nil
+}
+
+// Knn sets the top-level approximate kNN section. Elasticsearch 8.4 or later.
+func (b *Builder) Knn(params ...KnnSearchParams) *Builder {
+	b.knn = append(b.knn, params...)
+	return b
+}
+
+func (b *Builder) generateKnn(dc DataSource) (any, error) {
+	if len(b.knn) == 0 {
+		return nil, nil
+	}
+	if err := requireES(dc, "knn", 8, 4); err != nil {
+		return nil, err
+	}
+
+	knn := make([]any, len(b.knn))
+	for i, k := range b.knn {
+		v, err := k.generate(dc)
+		if err != nil {
+			return nil, err
+		}
+		knn[i] = v
+	}
+	if len(knn) == 1 {
+		return knn[0], nil
+	}
+	return knn, nil
+}
+
+type Retriever interface {
+	retriever(dc DataSource) (any, error)
+}
+
+type standardRetriever struct {
+	query  Query
+	filter []Query
+}
+
+func (r *standardRetriever) retriever(dc DataSource) (any, error) {
+	query, err := render(r.query, dc, "StandardRetriever", "query")
+	if err != nil {
+		return nil, err
+	}
+	filter, err := renderAll(r.filter, dc, "StandardRetriever", "filter")
+	if err != nil {
+		return nil, err
+	}
+
+	return struct {
+		Standard any `json:"standard"`
+	}{
+		struct {
+			Query  any   `json:"query"`
+			Filter []any `json:"filter,omitempty"`
+		}{
+			query,
+			filter,
+		},
+	}, nil
+}
+
+func StandardRetriever(query Query, filter ...Query) Retriever {
+	return &standardRetriever{query, filter}
+}
+
+type knnRetriever struct {
+	params KnnSearchParams
+}
+
+func (r *knnRetriever) retriever(dc DataSource) (any, error) {
+	knn, err := r.params.generate(dc)
+	if err != nil {
+		return nil, err
+	}
+	return struct {
+		Knn any `json:"knn"`
+	}{knn}, nil
+}
+
+func KnnRetriever(params KnnSearchParams) Retriever {
+	return &knnRetriever{params}
+}
+
+type rrfRetriever struct {
+	retrievers     []Retriever
+	rankConstant   int
+	rankWindowSize int
+}
+
+func (r *rrfRetriever) retriever(dc DataSource) (any, error) {
+	retrievers := make([]any, len(r.retrievers))
+	for i, c := range r.retrievers {
+		if c == nil {
+			return nil, &BuildError{"RRFRetriever", fmt.Sprintf("retrievers[%d]", i), fmt.Errorf("%w: nil retriever", ErrWrongNodeKind)}
+		}
+		v, err := c.retriever(dc)
+		if err != nil {
+			return nil, err
+		}
+		retrievers[i] = v
+	}
+
+	return struct {
+		RRF any `json:"rrf"`
+	}{
+		struct {
+			Retrievers     []any `json:"retrievers"`
+			RankConstant   int   `json:"rank_constant,omitempty"`
+			RankWindowSize int   `json:"rank_window_size,omitempty"`
+		}{
+			retrievers,
+			r.rankConstant,
+			r.rankWindowSize,
+		},
+	}, nil
+}
+
+// RRFRetriever merges the child retrievers with reciprocal rank fusion.
+// Zero rankConstant and rankWindowSize keep the server defaults.
+func RRFRetriever(rankConstant, rankWindowSize int, retrievers ...Retriever) Retriever {
+	return &rrfRetriever{retrievers, rankConstant, rankWindowSize}
+}
+
+// Retriever replaces query and knn. Elasticsearch 8.14 or later.
+func (b *Builder) Retriever(r Retriever) *Builder {
+	b.retriever = r
+	return b
+}
+
+func (b *Builder) generateRetriever(dc DataSource) (any, error) {
+	if b.retriever == nil {
+		return nil, nil
+	}
+	if err := requireES(dc, "retriever", 8, 14); err != nil {
+		return nil, err
+	}
+	if b.query != nil || len(b.knn) > 0 {
+		return nil, fmt.Errorf("%w: retriever cannot be combined with query or knn", ErrUnsupportedQuery)
+	}
+	return b.retriever.retriever(dc)
+}
@@ -0,0 +1,161 @@
+package queryBuilder_test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/linksports/queryBuilder"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestElasticSearchVersion(t *testing.T) {
+	t.Run("data source", func(t *testing.T) {
+		assert.Equal(t, queryBuilder.ES8, queryBuilder.ESVersion("8"))
+		assert.Equal(t, queryBuilder.ES, queryBuilder.ESVersion("8.14").Dialect())
+		assert.Equal(t, "8.14", queryBuilder.ESVersion("8.14").Version())
+
+		for _, dc := range []queryBuilder.DataSource{queryBuilder.ES6, queryBuilder.ES7, queryBuilder.ES8, queryBuilder.ESVersion("8.14.1")} {
+			query, err := queryBuilder.New().Query(queryBuilder.MatchAll()).Build(dc)
+			assert.NoError(t, err)
+			assert.Equal(t, `{"query":{"match_all":{}}}`, query)
+		}
+
+		for _, dc := range []queryBuilder.DataSource{queryBuilder.ESVersion("5.6"), queryBuilder.ESVersion("latest")} {
+			_, err := queryBuilder.New().Query(queryBuilder.MatchAll()).Build(dc)
+			assert.ErrorIs(t, err, queryBuilder.ErrUnsupportedDataSource)
+		}
+	})
+
+	t.Run("track_total_hits", func(t *testing.T) {
+		builder := queryBuilder.New().Query(queryBuilder.MatchAll()).TrackTotalHits(true)
+		query, err := builder.Build(queryBuilder.ES7)
+
+		assert.NoError(t, err)
+		assert.Equal(t, queryBuilder.Trim(`{
+			"query":{"match_all":{}},
+			"track_total_hits":true
+		}`), query)
+
+		query, err = builder.Build(queryBuilder.ES6)
+		assert.NoError(t, err)
+		assert.Equal(t, `{"query":{"match_all":{}},"track_total_hits":true}`, query)
+
+		_, err = builder.TrackTotalHits(10000).Build(queryBuilder.ES6)
+		assert.ErrorIs(t, err, queryBuilder.ErrUnsupportedQuery)
+
+		query, err = builder.Build(queryBuilder.ES7)
+		assert.NoError(t, err)
+		assert.Equal(t, `{"query":{"match_all":{}},"track_total_hits":10000}`, query)
+	})
+
+	t.Run("terms order _term", func(t *testing.T) {
+		builder := queryBuilder.New().Aggs(
+			queryBuilder.TermsAgg(queryBuilder.AggregateParams{
+				Name:      "team_term",
+				FieldName: "team",
+				Order:     map[string]string{"_term": "asc"},
+			}),
+		)
+
+		query, warnings, err := builder.BuildWithWarnings(queryBuilder.ES6)
+		assert.NoError(t, err)
+		assert.Equal(t, `{"aggs":{"team_term":{"terms":{"field":"team","order":{"_term":"asc"}}}}}`, query)
+		assert.Equal(t, []string{"aggs.team_term: ordering by _term is deprecated on ElasticSearch/6, use _key"}, warnings)
+
+		query, warnings, err = builder.BuildWithWarnings(queryBuilder.ES8)
+		assert.NoError(t, err)
+		assert.Equal(t, `{"aggs":{"team_term":{"terms":{"field":"team","order":{"_key":"asc"}}}}}`, query)
+		assert.Equal(t, []string{"aggs.team_term: ordering by _term was removed in Elasticsearch 8.0, rendered as _key"}, warnings)
+
+		query, warnings, err = builder.BuildWithWarnings(queryBuilder.ES7)
+		assert.NoError(t, err)
+		assert.Equal(t, `{"aggs":{"team_term":{"terms":{"field":"team","order":{"_key":"asc"}}}}}`, query)
+		assert.Equal(t, []string{"aggs.team_term: ordering by _term is deprecated since Elasticsearch 6.0, rendered as _key"}, warnings)
+
+		_, warnings, err = builder.ClearAggs().BuildWithWarnings(queryBuilder.ES8)
+		assert.NoError(t, err)
+		assert.Empty(t, warnings)
+	})
+
+	t.Run("concurrent builds", func(t *testing.T) {
+		builder := queryBuilder.New().Aggs(
+			queryBuilder.TermsAgg(queryBuilder.AggregateParams{Name: "team_term", FieldName: "team", Order: map[string]string{"_term": "asc"}}),
+		)
+
+		var wg sync.WaitGroup
+		for _, dc := range []queryBuilder.DataSource{queryBuilder.ES6, queryBuilder.ES7, queryBuilder.ES8, queryBuilder.OpenSearch} {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, warnings, err := builder.BuildWithWarnings(dc)
+				assert.NoError(t, err)
+				assert.Len(t, warnings, 1)
+			}()
+		}
+		wg.Wait()
+	})
+
+	t.Run("knn", func(t *testing.T) {
+		builder := queryBuilder.New().Knn(queryBuilder.KnnSearchParams{
+			Field:         "embedding",
+			QueryVector:   []float32{0.5, 1},
+			K:             10,
+			NumCandidates: 100,
+			Filter:        queryBuilder.Term("sport_id", 1),
+		})
+
+		query, err := builder.Build(queryBuilder.ESVersion("8.4"))
+		assert.NoError(t, err)
+		assert.Equal(t, queryBuilder.Trim(`{
+			"knn":{
+				"field":"embedding",
+				"query_vector":[0.5,1],
+				"k":10,
+				"num_candidates":100,
+				"filter":{"term":{"sport_id":1}}
+			}
+		}`), query)
+
+		for _, dc := range []queryBuilder.DataSource{queryBuilder.ES7, queryBuilder.ESVersion("8.3"), queryBuilder.OpenSearch} {
+			_, err := builder.Build(dc)
+			assert.ErrorIs(t, err, queryBuilder.ErrUnsupportedQuery)
+		}
+	})
+
+	t.Run("retriever", func(t *testing.T) {
+		builder := queryBuilder.New().Retriever(
+			queryBuilder.RRFRetriever(60, 0,
+				queryBuilder.StandardRetriever(queryBuilder.Match("name", "tokyo")),
+				queryBuilder.KnnRetriever(queryBuilder.KnnSearchParams{
+					Field:       "embedding",
+					QueryVector: []float32{1, 2},
+					K:           5,
+				}),
+			),
+		)
+
+		query, err := builder.Build(queryBuilder.ESVersion("8.14"))
+		assert.NoError(t, err)
+		assert.Equal(t, queryBuilder.Trim(`{
+			"retriever":{
+				"rrf":{
+					"retrievers":[
+						{"standard":{"query":{"match":{"name":"tokyo"}}}},
+						{"knn":{"field":"embedding","query_vector":[1,2],"k":5}}
+					],
+					"rank_constant":60
+				}
+			}
+		}`), query)
+
+		_, err = builder.Build(queryBuilder.ESVersion("8.13"))
+		assert.ErrorIs(t, err, queryBuilder.ErrUnsupportedQuery)
+
+		_, err = builder.Query(queryBuilder.MatchAll()).Build(queryBuilder.ESVersion("8.14"))
+		assert.ErrorIs(t, err, queryBuilder.ErrUnsupportedQuery)
+
+		_, err = queryBuilder.New().Retriever(queryBuilder.RRFRetriever(0, 0, nil)).Build(queryBuilder.ESVersion("8.14"))
+		assert.ErrorIs(t, err, queryBuilder.ErrWrongNodeKind)
+		assert.EqualError(t, err, "RRFRetriever(retrievers[0]): wrong node kind: nil retriever")
+	})
+}
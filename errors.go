@@ -0,0 +1,56 @@
+package queryBuilder
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+var (
+	ErrWrongNodeKind = errors.New("wrong node kind")
+	ErrEmptyField    = errors.New("empty field name")
+	ErrEmptyName     = errors.New("empty name")
+	ErrDuplicateName = errors.New("duplicate name")
+	ErrNegative      = errors.New("negative value")
+	ErrEmptyValues   = errors.New("empty values")
+	ErrInvalidValue  = errors.New("invalid value")
+	ErrBucketsPath   = errors.New("invalid buckets_path")
+	ErrInvalidCursor = errors.New("invalid cursor")
+)
+
+// BuildError reports a misused builder call. Build returns every BuildError
+// found, joined with errors.Join; match them with errors.As or errors.Is.
+type BuildError struct {
+	Call string // e.g. "Builder.Aggs", "BoolQuery.Must", "Term"
+	Arg  string // e.g. "values[1]", "field"
+	Err  error
+}
+
+func (e *BuildError) Error() string {
+	return fmt.Sprintf("%s(%s): %v", e.Call, e.Arg, e.Err)
+}
+
+func (e *BuildError) Unwrap() error {
+	return e.Err
+}
+
+func requireField(call, field string) error {
+	if field == "" {
+		return &BuildError{call, "field", ErrEmptyField}
+	}
+	return nil
+}
+
+func requireValues(call, arg string, values any) error {
+	v := reflect.ValueOf(values)
+	if !v.IsValid() || (v.Kind() == reflect.Slice || v.Kind() == reflect.Array) && v.Len() == 0 {
+		return &BuildError{call, arg, ErrEmptyValues}
+	}
+	return nil
+}
+
+// Err returns the misuse recorded by builder calls so far, for renderers
+// that do not go through the Elasticsearch renderer.
+func (b *Builder) Err() error {
+	return errors.Join(b.errs...)
+}
@@ -0,0 +1,103 @@
+package queryBuilder_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/linksports/queryBuilder"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildError(t *testing.T) {
+	t.Run("nil passed to Aggs", func(t *testing.T) {
+		var query string
+		var err error
+		assert.NotPanics(t, func() {
+			query, err = queryBuilder.New().Aggs(
+				queryBuilder.TermsAgg(queryBuilder.AggregateParams{Name: "a", FieldName: "a"}),
+				nil,
+			).Build(queryBuilder.ES)
+		})
+
+		assert.Empty(t, query)
+		assert.ErrorIs(t, err, queryBuilder.ErrWrongNodeKind)
+
+		var buildErr *queryBuilder.BuildError
+		assert.ErrorAs(t, err, &buildErr)
+		assert.Equal(t, "Builder.Aggs", buildErr.Call)
+		assert.Equal(t, "values[1]", buildErr.Arg)
+	})
+
+	t.Run("all misuse is reported", func(t *testing.T) {
+		_, err := queryBuilder.New().Query(
+			queryBuilder.Bool().Must(
+				queryBuilder.Match("name", "tokyo"),
+				queryBuilder.Term("", "v"),
+			).Filter(
+				queryBuilder.Terms("sport_id", []int{}),
+			),
+		).Aggs(
+			queryBuilder.TermsAgg(queryBuilder.AggregateParams{Name: "team", FieldName: "team_id"}),
+			queryBuilder.TermsAgg(queryBuilder.AggregateParams{Name: "team", FieldName: "team_name"}),
+		).Size(-1).From(-10).Build(queryBuilder.ES)
+
+		for _, target := range []error{
+			queryBuilder.ErrEmptyField,
+			queryBuilder.ErrEmptyValues,
+			queryBuilder.ErrDuplicateName,
+			queryBuilder.ErrNegative,
+		} {
+			assert.ErrorIs(t, err, target)
+		}
+
+		joined, ok := err.(interface{ Unwrap() []error })
+		assert.True(t, ok)
+
+		var messages []string
+		for _, e := range joined.Unwrap() {
+			messages = append(messages, e.Error())
+		}
+		assert.Equal(t, []string{
+			"Builder.Size(value): negative value",
+			"Builder.From(value): negative value",
+			"Builder.Query(query): BoolQuery.Must(g[1]): Term(field): empty field name\nBoolQuery.Filter(g[0]): Terms(values): empty values",
+			`Builder.Aggs(values[1]): duplicate name: "team"`,
+		}, messages)
+	})
+
+	t.Run("function score", func(t *testing.T) {
+		_, err := queryBuilder.New().Query(
+			queryBuilder.FunctionScore(nil, []queryBuilder.Function{
+				{Weight: 1},
+				{Filter: queryBuilder.Exists(""), Weight: 2},
+			}),
+		).Build(queryBuilder.ES)
+
+		assert.ErrorIs(t, err, queryBuilder.ErrWrongNodeKind)
+		assert.ErrorIs(t, err, queryBuilder.ErrEmptyField)
+		assert.True(t, strings.Contains(err.Error(), "FunctionScore(functions[1].Filter): Exists(field): empty field name"))
+	})
+
+	t.Run("terms agg params", func(t *testing.T) {
+		_, err := queryBuilder.New().Aggs(
+			queryBuilder.TermsAgg(queryBuilder.AggregateParams{FieldName: "team"}),
+			queryBuilder.TermsAgg(queryBuilder.AggregateParams{Name: "team"}),
+			queryBuilder.TermsAgg(queryBuilder.AggregateParams{Name: "size", FieldName: "team", Size: -1}),
+		).Build(queryBuilder.ES)
+
+		assert.ErrorIs(t, err, queryBuilder.ErrEmptyName)
+		assert.ErrorIs(t, err, queryBuilder.ErrEmptyField)
+		assert.ErrorIs(t, err, queryBuilder.ErrNegative)
+	})
+
+	t.Run("Err", func(t *testing.T) {
+		builder := queryBuilder.New()
+		assert.NoError(t, builder.Err())
+
+		builder.Size(-1)
+		var buildErr *queryBuilder.BuildError
+		assert.True(t, errors.As(builder.Err(), &buildErr))
+		assert.Equal(t, "Builder.Size", buildErr.Call)
+	})
+}
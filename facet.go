@@ -0,0 +1,83 @@
+package queryBuilder
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// PostFilter narrows the hits after aggregations are computed, so the
+// aggregations still see the whole result set.
+func (b *Builder) PostFilter(query Query) *Builder {
+	b.postFilter = query
+	return b
+}
+
+// Facet is an aggregation of faceted navigation and the filter for the
+// values the user selected in it, nil when nothing is selected.
+type Facet struct {
+	Agg      Aggregation
+	Selected Query
+}
+
+// Facets adds facets to those already added. At Build, the post_filter
+// becomes the query set by PostFilter plus the selections of every facet,
+// and each facet's aggregation is wrapped in a filter aggregation of the
+// same name that applies the PostFilter query and the selections of all the
+// other facets. Read the buckets from aggregations.<name>.<name>.
+func (b *Builder) Facets(facets ...Facet) *Builder {
+	b.facets = append(b.facets, facets...)
+	return b
+}
+
+// facetFilters returns the post_filter and the aggregations of the facets
+// added by Facets; facets without an aggregation are reported by checkFacets.
+func (b *Builder) facetFilters() (Query, []Aggregation) {
+	if len(b.facets) == 0 {
+		return b.postFilter, nil
+	}
+
+	var base []Query
+	if b.postFilter != nil {
+		base = append(base, b.postFilter)
+	}
+	selected := slices.Clone(base)
+	for _, f := range b.facets {
+		if f.Selected != nil {
+			selected = append(selected, f.Selected)
+		}
+	}
+	postFilter := b.postFilter
+	if len(selected) > len(base) {
+		postFilter = Bool().Filter(selected...)
+	}
+
+	var aggs []Aggregation
+	for i, f := range b.facets {
+		if f.Agg == nil {
+			continue
+		}
+		others := slices.Clone(base)
+		for j, o := range b.facets {
+			if j != i && o.Selected != nil {
+				others = append(others, o.Selected)
+			}
+		}
+		var filter Query = MatchAll()
+		if len(others) > 0 {
+			filter = Bool().Filter(others...)
+		}
+		aggs = append(aggs, FilterAgg(f.Agg.AggregationName(), filter).SubAggs(f.Agg))
+	}
+	return postFilter, aggs
+}
+
+func (b *Builder) checkFacets() error {
+	var errs []error
+	for i, f := range b.facets {
+		if f.Agg == nil {
+			errs = append(errs, &BuildError{"Builder.Facets", fmt.Sprintf("facets[%d].Agg", i), fmt.Errorf("%w: nil aggregation", ErrWrongNodeKind)})
+		}
+	}
+	return errors.Join(errs...)
+}
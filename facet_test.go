@@ -0,0 +1,130 @@
+package queryBuilder_test
+
+import (
+	"testing"
+
+	"github.com/linksports/queryBuilder"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFacets(t *testing.T) {
+	t.Run("post_filter", func(t *testing.T) {
+		query, err := queryBuilder.New().Query(
+			queryBuilder.Match("name", "shirt"),
+		).Aggs(
+			queryBuilder.TermsAgg(queryBuilder.AggregateParams{Name: "colors", FieldName: "color"}),
+		).PostFilter(
+			queryBuilder.Term("color", "red"),
+		).Build(queryBuilder.ES)
+
+		assert.NoError(t, err)
+		assert.Equal(t, queryBuilder.Trim(`{
+			"query":{"match":{"name":"shirt"}},
+			"aggs":{"colors":{"terms":{"field":"color"}}},
+			"post_filter":{"term":{"color":"red"}}
+		}`), query)
+
+		_, err = queryBuilder.New().PostFilter(queryBuilder.Term("", "red")).Build(queryBuilder.ES)
+		assert.ErrorIs(t, err, queryBuilder.ErrEmptyField)
+		assert.ErrorContains(t, err, "Builder.PostFilter(query)")
+	})
+
+	t.Run("facets", func(t *testing.T) {
+		query, err := queryBuilder.New().Query(
+			queryBuilder.Match("name", "shirt"),
+		).Facets(
+			queryBuilder.Facet{
+				Agg:      queryBuilder.TermsAgg(queryBuilder.AggregateParams{Name: "color", FieldName: "color"}),
+				Selected: queryBuilder.Terms("color", []string{"red", "blue"}),
+			},
+			queryBuilder.Facet{
+				Agg:      queryBuilder.TermsAgg(queryBuilder.AggregateParams{Name: "size", FieldName: "size"}),
+				Selected: queryBuilder.Term("size", "M"),
+			},
+			queryBuilder.Facet{
+				Agg: queryBuilder.TermsAgg(queryBuilder.AggregateParams{Name: "brand", FieldName: "brand"}),
+			},
+		).Build(queryBuilder.ES)
+
+		assert.NoError(t, err)
+		assert.Equal(t, queryBuilder.Trim(`{
+			"query":{"match":{"name":"shirt"}},
+			"aggs":{
+				"brand":{
+					"aggs":{"brand":{"terms":{"field":"brand"}}},
+					"filter":{"bool":{"filter":[{"terms":{"color":["red","blue"]}},{"term":{"size":"M"}}]}}
+				},
+				"color":{
+					"aggs":{"color":{"terms":{"field":"color"}}},
+					"filter":{"bool":{"filter":[{"term":{"size":"M"}}]}}
+				},
+				"size":{
+					"aggs":{"size":{"terms":{"field":"size"}}},
+					"filter":{"bool":{"filter":[{"terms":{"color":["red","blue"]}}]}}
+				}
+			},
+			"post_filter":{"bool":{"filter":[{"terms":{"color":["red","blue"]}},{"term":{"size":"M"}}]}}
+		}`), query)
+	})
+
+	t.Run("with post_filter", func(t *testing.T) {
+		query, err := queryBuilder.New().PostFilter(
+			queryBuilder.Term("in_stock", true),
+		).Facets(
+			queryBuilder.Facet{
+				Agg:      queryBuilder.TermsAgg(queryBuilder.AggregateParams{Name: "color", FieldName: "color"}),
+				Selected: queryBuilder.Term("color", "red"),
+			},
+		).Build(queryBuilder.ES)
+
+		assert.NoError(t, err)
+		assert.Equal(t, queryBuilder.Trim(`{
+			"aggs":{
+				"color":{
+					"aggs":{"color":{"terms":{"field":"color"}}},
+					"filter":{"bool":{"filter":[{"term":{"in_stock":true}}]}}
+				}
+			},
+			"post_filter":{"bool":{"filter":[{"term":{"in_stock":true}},{"term":{"color":"red"}}]}}
+		}`), query)
+	})
+
+	t.Run("several calls", func(t *testing.T) {
+		query, err := queryBuilder.New().Facets(
+			queryBuilder.Facet{Agg: queryBuilder.TermsAgg(queryBuilder.AggregateParams{Name: "color", FieldName: "color"})},
+		).Facets(
+			queryBuilder.Facet{
+				Agg:      queryBuilder.TermsAgg(queryBuilder.AggregateParams{Name: "size", FieldName: "size"}),
+				Selected: queryBuilder.Term("size", "M"),
+			},
+		).Build(queryBuilder.ES)
+
+		assert.NoError(t, err)
+		assert.Equal(t, queryBuilder.Trim(`{
+			"aggs":{
+				"color":{
+					"aggs":{"color":{"terms":{"field":"color"}}},
+					"filter":{"bool":{"filter":[{"term":{"size":"M"}}]}}
+				},
+				"size":{
+					"aggs":{"size":{"terms":{"field":"size"}}},
+					"filter":{"match_all":{}}
+				}
+			},
+			"post_filter":{"bool":{"filter":[{"term":{"size":"M"}}]}}
+		}`), query)
+	})
+
+	t.Run("nothing selected", func(t *testing.T) {
+		query, err := queryBuilder.New().Facets(
+			queryBuilder.Facet{Agg: queryBuilder.TermsAgg(queryBuilder.AggregateParams{Name: "color", FieldName: "color"})},
+		).Build(queryBuilder.ES)
+
+		assert.NoError(t, err)
+		assert.Equal(t, `{"aggs":{"color":{"aggs":{"color":{"terms":{"field":"color"}}},"filter":{"match_all":{}}}}}`, query)
+
+		_, err = queryBuilder.New().Facets(queryBuilder.Facet{Selected: queryBuilder.Term("a", "b")}).Build(queryBuilder.ES)
+		assert.ErrorIs(t, err, queryBuilder.ErrWrongNodeKind)
+		assert.ErrorContains(t, err, "Builder.Facets(facets[0].Agg)")
+	})
+}
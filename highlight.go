@@ -0,0 +1,130 @@
+package queryBuilder
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// HighlightParams are the settings shared by the highlight section and its
+// fields; a field's settings override the section's.
+type HighlightParams struct {
+	Type              string // unified, plain or fvh
+	FragmentSize      int
+	NumberOfFragments *int // 0 highlights the whole field
+	PreTags           []string
+	PostTags          []string
+	HighlightQuery    Query
+	RequireFieldMatch *bool
+	BoundaryScanner   string // chars, sentence or word
+}
+
+var (
+	highlighterTypes = []string{"", "unified", "plain", "fvh"}
+	boundaryScanners = []string{"", "chars", "sentence", "word"}
+)
+
+func (p HighlightParams) generate(dc DataSource, call, arg string) (map[string]any, error) {
+	if !slices.Contains(highlighterTypes, p.Type) {
+		return nil, &BuildError{call, arg + ".Type", fmt.Errorf("%w: highlighter %q", ErrInvalidValue, p.Type)}
+	}
+	if !slices.Contains(boundaryScanners, p.BoundaryScanner) {
+		return nil, &BuildError{call, arg + ".BoundaryScanner", fmt.Errorf("%w: %q", ErrInvalidValue, p.BoundaryScanner)}
+	}
+	if p.FragmentSize < 0 {
+		return nil, &BuildError{call, arg + ".FragmentSize", ErrNegative}
+	}
+	if p.NumberOfFragments != nil && *p.NumberOfFragments < 0 {
+		return nil, &BuildError{call, arg + ".NumberOfFragments", ErrNegative}
+	}
+
+	body := map[string]any{}
+	setIf(body, "type", p.Type, p.Type != "")
+	setIf(body, "fragment_size", p.FragmentSize, p.FragmentSize != 0)
+	if p.NumberOfFragments != nil {
+		body["number_of_fragments"] = *p.NumberOfFragments
+	}
+	setIf(body, "pre_tags", p.PreTags, len(p.PreTags) > 0)
+	setIf(body, "post_tags", p.PostTags, len(p.PostTags) > 0)
+	if p.HighlightQuery != nil {
+		q, err := render(p.HighlightQuery, dc, call, arg+".HighlightQuery")
+		if err != nil {
+			return nil, err
+		}
+		body["highlight_query"] = q
+	}
+	if p.RequireFieldMatch != nil {
+		body["require_field_match"] = *p.RequireFieldMatch
+	}
+	setIf(body, "boundary_scanner", p.BoundaryScanner, p.BoundaryScanner != "")
+	return body, nil
+}
+
+type HighlightField struct {
+	Name   string
+	Params HighlightParams
+}
+
+type Highlight struct {
+	defaults HighlightParams
+	fields   []HighlightField
+}
+
+// NewHighlight starts a highlight section whose fields default to defaults.
+func NewHighlight(defaults HighlightParams) *Highlight {
+	return &Highlight{defaults: defaults}
+}
+
+// Field highlights the field name, wildcards allowed.
+func (h *Highlight) Field(name string, params HighlightParams) *Highlight {
+	h.fields = append(h.fields, HighlightField{name, params})
+	return h
+}
+
+func (h *Highlight) generate(dc DataSource) (any, error) {
+	if len(h.fields) == 0 {
+		return nil, &BuildError{"Highlight.Field", "name", fmt.Errorf("%w: no fields to highlight", ErrEmptyValues)}
+	}
+
+	body, err := h.defaults.generate(dc, "NewHighlight", "defaults")
+	if err != nil {
+		return nil, err
+	}
+	var errs []error
+	fields := make(map[string]any, len(h.fields))
+	for i, f := range h.fields {
+		arg := fmt.Sprintf("fields[%d]", i)
+		if f.Name == "" {
+			errs = append(errs, &BuildError{"Highlight.Field", arg, ErrEmptyField})
+			continue
+		}
+		if _, dup := fields[f.Name]; dup {
+			errs = append(errs, &BuildError{"Highlight.Field", arg, fmt.Errorf("%w: %q", ErrDuplicateName, f.Name)})
+			continue
+		}
+		field, err := f.Params.generate(dc, "Highlight.Field", arg)
+		errs = append(errs, err)
+		fields[f.Name] = field
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	body["fields"] = fields
+	return body, nil
+}
+
+func (b *Builder) Highlight(h *Highlight) *Builder {
+	b.highlight = h
+	return b
+}
+
+func (b *Builder) generateHighlight(dc DataSource) (any, error) {
+	if b.highlight == nil {
+		return nil, nil
+	}
+	highlight, err := b.highlight.generate(dc)
+	if err != nil {
+		return nil, &BuildError{"Builder.Highlight", "h", err}
+	}
+	return highlight, nil
+}
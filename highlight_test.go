@@ -0,0 +1,75 @@
+package queryBuilder_test
+
+import (
+	"testing"
+
+	"github.com/linksports/queryBuilder"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHighlight(t *testing.T) {
+	t.Run("defaults+fields", func(t *testing.T) {
+		fragments := 0
+		requireMatch := false
+		query, err := queryBuilder.New().Query(
+			queryBuilder.Match("description", "tokyo"),
+		).Highlight(
+			queryBuilder.NewHighlight(queryBuilder.HighlightParams{
+				PreTags:  []string{"<mark>"},
+				PostTags: []string{"</mark>"},
+			}).Field("description", queryBuilder.HighlightParams{
+				Type:            "unified",
+				FragmentSize:    150,
+				BoundaryScanner: "sentence",
+			}).Field("name", queryBuilder.HighlightParams{
+				NumberOfFragments: &fragments,
+				HighlightQuery:    queryBuilder.Prefix("name", "tok"),
+				RequireFieldMatch: &requireMatch,
+			}),
+		).Build(queryBuilder.ES)
+
+		assert.NoError(t, err)
+		assert.Equal(t, queryBuilder.Trim(`{
+			"query":{"match":{"description":"tokyo"}},
+			"highlight":{
+				"fields":{
+					"description":{"boundary_scanner":"sentence","fragment_size":150,"type":"unified"},
+					"name":{"highlight_query":{"prefix":{"name":"tok"}},"number_of_fragments":0,"require_field_match":false}
+				},
+				"post_tags":["</mark>"],
+				"pre_tags":["<mark>"]
+			}
+		}`), query)
+	})
+
+	t.Run("clone", func(t *testing.T) {
+		h := queryBuilder.NewHighlight(queryBuilder.HighlightParams{}).Field("name", queryBuilder.HighlightParams{})
+		original := queryBuilder.New().Highlight(h)
+		clone := original.Clone()
+		h.Field("description", queryBuilder.HighlightParams{})
+
+		query, err := clone.Build(queryBuilder.ES)
+		assert.NoError(t, err)
+		assert.Equal(t, `{"highlight":{"fields":{"name":{}}}}`, query)
+
+		query, err = original.Build(queryBuilder.ES)
+		assert.NoError(t, err)
+		assert.Equal(t, `{"highlight":{"fields":{"description":{},"name":{}}}}`, query)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		_, err := queryBuilder.New().Highlight(queryBuilder.NewHighlight(queryBuilder.HighlightParams{})).Build(queryBuilder.ES)
+		assert.ErrorIs(t, err, queryBuilder.ErrEmptyValues)
+
+		_, err = queryBuilder.New().Highlight(
+			queryBuilder.NewHighlight(queryBuilder.HighlightParams{}).
+				Field("name", queryBuilder.HighlightParams{Type: "fast"}).
+				Field("name", queryBuilder.HighlightParams{}).
+				Field("body", queryBuilder.HighlightParams{HighlightQuery: queryBuilder.Term("", "x")}),
+		).Build(queryBuilder.ES)
+		assert.ErrorIs(t, err, queryBuilder.ErrInvalidValue)
+		assert.ErrorIs(t, err, queryBuilder.ErrDuplicateName)
+		assert.ErrorIs(t, err, queryBuilder.ErrEmptyField)
+		assert.ErrorContains(t, err, "Builder.Highlight(h): Highlight.Field(fields[0].Type)")
+	})
+}
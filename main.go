@@ -1,7 +1,12 @@
 package queryBuilder
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
 	"strings"
 
 	"github.com/aquasecurity/esquery"
@@ -10,124 +15,358 @@ import (
 type DataSource string
 
 const (
-	ES DataSource = "ElasticSearch"
+	ES         DataSource = "ElasticSearch"
+	OpenSearch DataSource = "OpenSearch"
 )
 
 type Builder struct {
-	query       any
+	query       Query
+	postFilter  Query
+	facets      []Facet
 	source      []string
-	sort        []map[string]any
+	excludes    []string
+	noSource    bool
+	stored      []string
+	docvalues   []DocvalueField
+	scripts     []ScriptField
+	highlight   *Highlight
+	suggest     []Suggester
+	collapse    *collapse
+	rescore     []Rescore
+	sort        []Sorter
 	size        int
 	from        int
-	searchAfter []string
-	aggs        map[string]map[string]any
+	searchAfter []any
+	aggs        []Aggregation
+	pipeline    *NormalizationParams
+	knn         []KnnSearchParams
+	retriever   Retriever
+	totalHits   any
+	extra       map[string]json.RawMessage
+	errs        []error
 }
 
 func New() *Builder {
 	return &Builder{}
 }
 
-type Generatable interface {
-	generate() any
+// Clone returns a copy of b that can be changed without affecting b.
+// Query and aggregation nodes are shared.
+func (b *Builder) Clone() *Builder {
+	c := *b
+	c.source = slices.Clone(b.source)
+	c.excludes = slices.Clone(b.excludes)
+	c.stored = slices.Clone(b.stored)
+	c.docvalues = slices.Clone(b.docvalues)
+	c.scripts = slices.Clone(b.scripts)
+	c.suggest = slices.Clone(b.suggest)
+	if b.highlight != nil {
+		h := *b.highlight
+		h.fields = slices.Clone(b.highlight.fields)
+		c.highlight = &h
+	}
+	c.rescore = slices.Clone(b.rescore)
+	c.sort = slices.Clone(b.sort)
+	c.searchAfter = slices.Clone(b.searchAfter)
+	c.aggs = slices.Clone(b.aggs)
+	c.facets = slices.Clone(b.facets)
+	c.knn = slices.Clone(b.knn)
+	c.extra = maps.Clone(b.extra)
+	c.errs = slices.Clone(b.errs)
+	return &c
+}
+
+// Query is implemented by every query node. Types outside the package can
+// implement it to add query kinds the package lacks; MarshalQuery returns a
+// value encoding/json can marshal, or ErrUnsupportedQuery for data sources
+// the type cannot be rendered for.
+type Query interface {
+	MarshalQuery(dc DataSource) (any, error)
+}
+
+// Generatable is the former name of Query.
+type Generatable = Query
+
+type Script struct {
+	Source string         `json:"source"`
+	Lang   string         `json:"lang,omitempty"`
+	Params map[string]any `json:"params,omitempty"`
+}
+
+// Sorter is an entry of the sort section, passed to Builder.SortBy, e.g.
+// FieldSort or GeoDistanceSort.
+type Sorter interface {
+	sortClause(dc DataSource) (any, error)
 }
 
 type Sort struct {
 	Field string
-	Order string // asc or desc
+	Order SortOrder
 }
 
 func (b *Builder) Build(dc DataSource) (string, error) {
-	switch dc {
-	case ES:
-		fallthrough
-	default:
-		return b.buildElasticSearch()
+	r, ok := LookupRenderer(dc)
+	if !ok {
+		return "", fmt.Errorf("%w: %q", ErrUnsupportedDataSource, dc)
+	}
+	return r.Render(dc, b)
+}
+
+// BuildWithWarnings is Build that also returns the deprecation warnings for
+// constructs the target version renders differently or removed.
+func (b *Builder) BuildWithWarnings(dc DataSource) (string, []string, error) {
+	r, ok := LookupRenderer(dc)
+	if !ok {
+		return "", nil, fmt.Errorf("%w: %q", ErrUnsupportedDataSource, dc)
 	}
+	if w, ok := r.(WarningRenderer); ok {
+		return w.RenderWithWarnings(dc, b)
+	}
+	query, err := r.Render(dc, b)
+	return query, nil, err
 }
 
-func (b *Builder) buildElasticSearch() (string, error) {
+func (b *Builder) buildElasticSearch(dc DataSource) (string, []string, error) {
+	if dc.Dialect() == ES {
+		if v, ok := dc.esVersion(); !ok || !v.atLeast(6, 0) {
+			return "", nil, fmt.Errorf("%w: %q", ErrUnsupportedDataSource, dc)
+		}
+	}
+
+	errs := slices.Clone(b.errs)
+
+	var query any
+	if b.query != nil {
+		q, err := render(b.query, dc, "Builder.Query", "query")
+		errs = append(errs, err)
+		query = q
+	}
+
+	filter, facetAggs := b.facetFilters()
+	errs = append(errs, b.checkFacets())
+
+	var postFilter any
+	if filter != nil {
+		q, err := render(filter, dc, "Builder.PostFilter", "query")
+		errs = append(errs, err)
+		postFilter = q
+	}
+
+	aggs, err := generateAggs(append(slices.Clone(b.aggs), facetAggs...), dc)
+	errs = append(errs, err)
+
+	var pipeline any
+	if b.pipeline != nil {
+		if dc.Dialect() != OpenSearch {
+			errs = append(errs, fmt.Errorf("%w: search_pipeline is not supported by %s", ErrUnsupportedQuery, dc))
+		}
+		pipeline = b.pipeline.generate()
+	}
+
+	sort, err := b.generateSort(dc)
+	errs = append(errs, err)
+	source, err := b.generateSource()
+	errs = append(errs, err)
+	docvalues, err := b.generateDocvalueFields(dc)
+	errs = append(errs, err)
+	scripts, err := b.generateScriptFields()
+	errs = append(errs, err)
+	highlight, err := b.generateHighlight(dc)
+	errs = append(errs, err)
+	suggest, err := b.generateSuggest(dc)
+	errs = append(errs, err)
+	collapse, err := b.generateCollapse(dc)
+	errs = append(errs, err)
+	rescore, err := b.generateRescore(dc)
+	errs = append(errs, err)
+	knn, err := b.generateKnn(dc)
+	errs = append(errs, err)
+	retriever, err := b.generateRetriever(dc)
+	errs = append(errs, err)
+	if _, isBool := b.totalHits.(bool); b.totalHits != nil && !isBool {
+		if v, ok := dc.esVersion(); ok && !v.atLeast(7, 0) {
+			errs = append(errs, fmt.Errorf("%w: track_total_hits as a number requires Elasticsearch 7.0 or later, got %s", ErrUnsupportedQuery, dc))
+		}
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return "", nil, err
+	}
+
 	body := struct {
-		Size        int                       `json:"size,omitempty"`
-		From        int                       `json:"from,omitempty"`
-		Query       any                       `json:"query,omitempty"`
-		Sort        []map[string]any          `json:"sort,omitempty"`
-		Source      []string                  `json:"_source,omitempty"`
-		SearchAfter []string                  `json:"search_after,omitempty"`
-		Aggs        map[string]map[string]any `json:"aggs,omitempty"`
+		Size           int            `json:"size,omitempty"`
+		From           int            `json:"from,omitempty"`
+		Query          any            `json:"query,omitempty"`
+		Knn            any            `json:"knn,omitempty"`
+		Retriever      any            `json:"retriever,omitempty"`
+		Sort           []any          `json:"sort,omitempty"`
+		Source         any            `json:"_source,omitempty"`
+		StoredFields   []string       `json:"stored_fields,omitempty"`
+		DocvalueFields []any          `json:"docvalue_fields,omitempty"`
+		ScriptFields   map[string]any `json:"script_fields,omitempty"`
+		SearchAfter    []any          `json:"search_after,omitempty"`
+		Collapse       any            `json:"collapse,omitempty"`
+		Rescore        []any          `json:"rescore,omitempty"`
+		Aggs           map[string]any `json:"aggs,omitempty"`
+		PostFilter     any            `json:"post_filter,omitempty"`
+		Highlight      any            `json:"highlight,omitempty"`
+		Suggest        map[string]any `json:"suggest,omitempty"`
+		TrackTotalHits any            `json:"track_total_hits,omitempty"`
+		SearchPipeline any            `json:"search_pipeline,omitempty"`
 	}{
 		b.size,
 		b.from,
-		b.query,
-		b.sort,
-		b.source,
+		query,
+		knn,
+		retriever,
+		sort,
+		source,
+		b.stored,
+		docvalues,
+		scripts,
 		b.searchAfter,
-		b.aggs,
+		collapse,
+		rescore,
+		aggs,
+		postFilter,
+		highlight,
+		suggest,
+		b.totalHits,
+		pipeline,
 	}
 
-	query, err := json.Marshal(body)
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	// <, > and & are written as is rather than as \u003c, \u003e and
+	// \u0026, e.g. in minimum_should_match "3<90%".
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(body); err != nil {
+		return "", nil, err
+	}
+	out, err := appendExtra(bytes.TrimSuffix(buf.Bytes(), []byte("\n")), b.extra)
 	if err != nil {
-		return "", err
+		return "", nil, err
 	}
-	return string(query), nil
+	return string(out), b.deprecations(dc), nil
 }
 
 func (b *Builder) Size(value int) *Builder {
+	if value < 0 {
+		b.errs = append(b.errs, &BuildError{"Builder.Size", "value", ErrNegative})
+	}
 	b.size = value
 	return b
 }
 
 func (b *Builder) From(value int) *Builder {
+	if value < 0 {
+		b.errs = append(b.errs, &BuildError{"Builder.From", "value", ErrNegative})
+	}
 	b.from = value
 	return b
 }
 
+// value is true, false or the number of hits to count accurately.
+func (b *Builder) TrackTotalHits(value any) *Builder {
+	b.totalHits = value
+	return b
+}
+
 func (b *Builder) Source(value []string) *Builder {
 	b.source = value
 	return b
 }
 
-func (b *Builder) Query(query Generatable) *Builder {
-	b.query = query.generate()
+func (b *Builder) Query(query Query) *Builder {
+	b.query = query
 	return b
 }
 
-func (b *Builder) Sort(sort ...Sort) *Builder {
-	if len(sort) > 0 {
-		sortList := make([]map[string]any, len(sort))
-		for i, s := range sort {
-			order := map[string]string{"order": s.Order}
-			m := map[string]any{}
-			m[s.Field] = order
-			sortList[i] = m
-		}
-		b.sort = append(b.sort, sortList...)
+func (s Sort) sortClause(dc DataSource) (any, error) {
+	if err := requireField("Sort", s.Field); err != nil {
+		return nil, err
+	}
+	if err := checkOrder("Sort", s.Order, true); err != nil {
+		return nil, err
 	}
+	order := map[string]SortOrder{"order": s.Order}
+	m := map[string]any{}
+	m[s.Field] = order
+	return m, nil
+}
 
+func (b *Builder) Sort(sort ...Sort) *Builder {
+	for _, s := range sort {
+		b.sort = append(b.sort, s)
+	}
 	return b
 }
 
-func (b *Builder) SearchAfter(values ...string) *Builder {
-	b.searchAfter = values
+// SortBy adds sort clauses of any kind, e.g. FieldSort or GeoDistanceSort.
+func (b *Builder) SortBy(sort ...Sorter) *Builder {
+	b.sort = append(b.sort, sort...)
 	return b
 }
 
-func (b *Builder) Aggs(values ...Generatable) *Builder {
-	aggs := make(map[string]map[string]any, len(values))
-	for _, a := range values {
-		for k, v := range a.generate().(map[string]map[string]any) {
-			aggs[k] = v
+func (b *Builder) generateSort(dc DataSource) ([]any, error) {
+	if len(b.sort) == 0 {
+		return nil, nil
+	}
+
+	var errs []error
+	sort := make([]any, len(b.sort))
+	for i, s := range b.sort {
+		arg := fmt.Sprintf("sort[%d]", i)
+		if s == nil {
+			errs = append(errs, &BuildError{"Builder.Sort", arg, fmt.Errorf("%w: nil sort", ErrWrongNodeKind)})
+			continue
+		}
+		clause, err := s.sortClause(dc)
+		if err != nil {
+			errs = append(errs, &BuildError{"Builder.Sort", arg, err})
 		}
+		sort[i] = clause
 	}
-	b.aggs = aggs
+	return sort, errors.Join(errs...)
+}
+
+// SearchAfter takes the sort values of the last hit. Decode responses with
+// json.Decoder.UseNumber so long values keep their precision.
+func (b *Builder) SearchAfter(values ...any) *Builder {
+	b.searchAfter = values
 	return b
 }
 
-type functionScore struct {
-	query     any
-	functions any
+type FunctionScoreQuery struct {
+	Query     Node
+	Functions []Function
 }
 
-func (f *functionScore) generate() any {
+func (f *FunctionScoreQuery) MarshalQuery(dc DataSource) (any, error) {
+	type functionType struct {
+		Filter any     `json:"filter,omitempty"`
+		Weight float32 `json:"weight"`
+	}
+
+	query, err := render(f.Query, dc, "FunctionScore", "query")
+	errs := []error{err}
+	list := make([]functionType, len(f.Functions))
+	for i, fn := range f.Functions {
+		var filter any
+		if fn.Filter != nil {
+			filter, err = render(fn.Filter, dc, "FunctionScore", fmt.Sprintf("functions[%d].Filter", i))
+			errs = append(errs, err)
+		}
+		list[i] = functionType{filter, fn.Weight}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	var functions any
+	if len(list) > 0 {
+		functions = list
+	}
+
 	return struct {
 		FunctionScore any `json:"function_score"`
 	}{
@@ -135,150 +374,153 @@ func (f *functionScore) generate() any {
 			Query     any `json:"query,omitempty"`
 			Functions any `json:"functions,omitempty"`
 		}{
-			f.query,
-			f.functions,
+			query,
+			functions,
 		},
-	}
+	}, nil
 }
 
 type Function struct {
-	Filter Generatable
+	Filter Query
 	Weight float32
 }
 
-func FunctionScore(query Generatable, functions []Function) Generatable {
-	type functionType struct {
-		Filter any     `json:"filter"`
-		Weight float32 `json:"weight"`
-	}
-	_functions := make([]functionType, len(functions))
-
-	for i, f := range functions {
-		_functions[i] = functionType{f.Filter.generate(), f.Weight}
-	}
-
-	return &functionScore{
-		query.generate(),
-		_functions,
+func FunctionScore(query Query, functions []Function) Query {
+	return &FunctionScoreQuery{
+		query,
+		functions,
 	}
 }
 
-type matchAll struct {
+type MatchAllQuery struct {
 }
 
-func (m *matchAll) generate() any {
+func (m *MatchAllQuery) MarshalQuery(dc DataSource) (any, error) {
 	return struct {
 		MatchAll any `json:"match_all"`
-	}{struct{}{}}
+	}{struct{}{}}, nil
 }
 
-func MatchAll() Generatable {
-	return &matchAll{}
+func MatchAll() Query {
+	return &MatchAllQuery{}
 }
 
-type match struct {
-	match map[string]string
+type MatchQuery struct {
+	Field string
+	Value string
 }
 
-func (t *match) generate() any {
+func (t *MatchQuery) MarshalQuery(dc DataSource) (any, error) {
+	if err := requireField("Match", t.Field); err != nil {
+		return nil, err
+	}
 	return struct {
 		Match map[string]string `json:"match,omitempty"`
-	}{t.match}
+	}{map[string]string{t.Field: t.Value}}, nil
 }
 
-func Match(field string, value string) Generatable {
-	m := map[string]string{}
-	m[field] = value
-	return &match{m}
+func Match(field string, value string) Query {
+	return &MatchQuery{field, value}
 }
 
-type matchPhrase struct {
-	matchPhrase map[string]string
+type MatchPhraseQuery struct {
+	Field string
+	Value string
 }
 
-func (m *matchPhrase) generate() any {
+func (m *MatchPhraseQuery) MarshalQuery(dc DataSource) (any, error) {
+	if err := requireField("MatchPhrase", m.Field); err != nil {
+		return nil, err
+	}
 	return struct {
 		MatchPhrase map[string]string `json:"match_phrase,omitempty"`
-	}{m.matchPhrase}
+	}{map[string]string{m.Field: m.Value}}, nil
 }
 
-func MatchPhrase(field string, value []string) Generatable {
-	m := map[string]string{}
-	m[field] = strings.Join(value, " ")
-	return &matchPhrase{m}
+func MatchPhrase(field string, value []string) Query {
+	return &MatchPhraseQuery{field, strings.Join(value, " ")}
 }
 
-type term struct {
-	term map[string]any
+type TermQuery struct {
+	Field string
+	Value any
 }
 
-func (t *term) generate() any {
+func (t *TermQuery) MarshalQuery(dc DataSource) (any, error) {
+	if err := requireField("Term", t.Field); err != nil {
+		return nil, err
+	}
 	return struct {
 		Term map[string]any `json:"term,omitempty"`
-	}{t.term}
+	}{map[string]any{t.Field: t.Value}}, nil
 }
 
-func Term(field string, value any) Generatable {
-	m := map[string]any{}
-	m[field] = value
-	return &term{m}
+func Term(field string, value any) Query {
+	return &TermQuery{field, value}
 }
 
-type terms struct {
-	terms map[string]any
+type TermsQuery struct {
+	Field  string
+	Values any // a slice
 }
 
-func (t *terms) generate() any {
+func (t *TermsQuery) MarshalQuery(dc DataSource) (any, error) {
+	if err := errors.Join(requireField("Terms", t.Field), requireValues("Terms", "values", t.Values)); err != nil {
+		return nil, err
+	}
 	return struct {
 		Terms map[string]any `json:"terms,omitempty"`
-	}{t.terms}
+	}{map[string]any{t.Field: t.Values}}, nil
 }
 
-func Terms[T any](field string, values []T) Generatable {
-	m := map[string]any{}
-	m[field] = values
-	return &terms{m}
+func Terms[T any](field string, values []T) Query {
+	return &TermsQuery{field, values}
 }
 
-type prefix struct {
-	prefix map[string]string
+type PrefixQuery struct {
+	Field string
+	Value string
 }
 
-func (t *prefix) generate() any {
+func (t *PrefixQuery) MarshalQuery(dc DataSource) (any, error) {
+	if err := requireField("Prefix", t.Field); err != nil {
+		return nil, err
+	}
 	return struct {
 		Prefix map[string]string `json:"prefix,omitempty"`
-	}{t.prefix}
+	}{map[string]string{t.Field: t.Value}}, nil
 }
 
-func Prefix(field string, values string) Generatable {
-	m := map[string]string{}
-	m[field] = values
-	return &prefix{m}
+func Prefix(field string, values string) Query {
+	return &PrefixQuery{field, values}
 }
 
-type exists struct {
-	fieldName string
+type ExistsQuery struct {
+	Field string
 }
 
-func (e *exists) generate() any {
+func (e *ExistsQuery) MarshalQuery(dc DataSource) (any, error) {
+	if err := requireField("Exists", e.Field); err != nil {
+		return nil, err
+	}
 	return struct {
 		Exists any `json:"exists"`
 	}{
 		struct {
 			Field string `json:"field"`
 		}{
-			e.fieldName,
+			e.Field,
 		},
-	}
+	}, nil
 }
 
-func Exists(field string) Generatable {
-	return &exists{field}
+func Exists(field string) Query {
+	return &ExistsQuery{field}
 }
 
-type rangeQuery struct {
-	fieldName string
-	params    RangeParams
+type RangeQuery struct {
+	Field  string
+	Params RangeParams
 }
 
 type RangeParams struct {
@@ -288,22 +530,25 @@ type RangeParams struct {
 	Lt  any `json:"lt,omitempty"`
 }
 
-func (r *rangeQuery) generate() any {
+func (r *RangeQuery) MarshalQuery(dc DataSource) (any, error) {
+	if err := requireField("Range", r.Field); err != nil {
+		return nil, err
+	}
 	rangeParamsMap := map[string]RangeParams{}
-	rangeParamsMap[r.fieldName] = r.params
+	rangeParamsMap[r.Field] = r.Params
 	return struct {
 		Range map[string]RangeParams `json:"range"`
 	}{
 		rangeParamsMap,
-	}
+	}, nil
 }
 
-func Range(field string, params RangeParams) Generatable {
-	return &rangeQuery{fieldName: field, params: params}
+func Range(field string, params RangeParams) Query {
+	return &RangeQuery{Field: field, Params: params}
 }
 
-type multiMatchQuery struct {
-	params esquery.MultiMatchQuery
+type MultiMatchQuery struct {
+	Params MultiMatchParams
 }
 
 type MultiMatchParams struct {
@@ -311,41 +556,28 @@ type MultiMatchParams struct {
 	Fields []string
 }
 
-func (m *multiMatchQuery) generate() any {
-	return m.params.Map()
-}
-
-func MultiMatch(params MultiMatchParams) Generatable {
+func (m *MultiMatchQuery) MarshalQuery(dc DataSource) (any, error) {
 	q := esquery.MultiMatch()
-	q.Fields(params.Fields...).Query(params.Query)
-	return &multiMatchQuery{params: *q}
+	q.Fields(m.Params.Fields...).Query(m.Params.Query)
+	return q.Map(), nil
 }
 
-type aggregationQuery struct {
-	params esquery.TermsAggregation
+func MultiMatch(params MultiMatchParams) Query {
+	return &MultiMatchQuery{Params: params}
 }
 
-type AggregateParams struct {
-	Name      string
-	FieldName string
-	Order     map[string]string
-	Size      int
+type RawQuery struct {
+	JSON json.RawMessage
 }
 
-func (m *aggregationQuery) generate() any {
-	return map[string]map[string]any{
-		m.params.Name(): m.params.Map(),
+func (r *RawQuery) MarshalQuery(dc DataSource) (any, error) {
+	if !json.Valid(r.JSON) {
+		return nil, fmt.Errorf("queryBuilder: invalid raw query %q", r.JSON)
 	}
+	return r.JSON, nil
 }
 
-func TermsAgg(params AggregateParams) Generatable {
-	q := esquery.TermsAgg(params.Name, params.FieldName)
-	if params.Size != 0 {
-		q.Size(uint64(params.Size))
-	}
-	if params.Order != nil {
-		q.Order(params.Order)
-	}
-
-	return &aggregationQuery{params: *q}
+// Raw embeds a query DSL snippet verbatim.
+func Raw(query json.RawMessage) Query {
+	return &RawQuery{query}
 }
@@ -1,6 +1,8 @@
 package queryBuilder_test
 
 import (
+	"encoding/json"
+	"fmt"
 	"testing"
 
 	"github.com/linksports/queryBuilder"
@@ -36,6 +38,15 @@ func TestQuery(t *testing.T) {
 		}`), query)
 	})
 
+	t.Run("html characters", func(t *testing.T) {
+		query, err := queryBuilder.New().Query(
+			queryBuilder.Match("name", "<b>Tom & Jerry</b>"),
+		).Build(queryBuilder.ES)
+
+		assert.NoError(t, err)
+		assert.Equal(t, `{"query":{"match":{"name":"<b>Tom & Jerry</b>"}}}`, query)
+	})
+
 	t.Run("match_phrase", func(t *testing.T) {
 		builder := queryBuilder.New()
 		query, err := builder.Query(
@@ -327,6 +338,13 @@ func TestQuery(t *testing.T) {
 		}`), query)
 	})
 
+	t.Run("search_after typed", func(t *testing.T) {
+		query, err := queryBuilder.New().SearchAfter(json.Number("1700000000000"), int64(9007199254740993), nil, "tokyo").Build(queryBuilder.ES)
+
+		assert.NoError(t, err)
+		assert.Equal(t, `{"search_after":[1700000000000,9007199254740993,null,"tokyo"]}`, query)
+	})
+
 	t.Run("exists", func(t *testing.T) {
 		builder := queryBuilder.New()
 		query, err := builder.Query(
@@ -428,3 +446,125 @@ func TestQuery(t *testing.T) {
 		}`), query)
 	})
 }
+
+func TestClone(t *testing.T) {
+	original := queryBuilder.New().Query(
+		queryBuilder.Term("target", "v"),
+	).Sort(queryBuilder.Sort{"sort1", "asc"}).Size(10)
+
+	clone := original.Clone().Sort(queryBuilder.Sort{"sort2", "desc"}).Size(20)
+
+	query, err := original.Build(queryBuilder.ES)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"size":10,"query":{"term":{"target":"v"}},"sort":[{"sort1":{"order":"asc"}}]}`, query)
+
+	query, err = clone.Build(queryBuilder.ES)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"size":20,"query":{"term":{"target":"v"}},"sort":[{"sort1":{"order":"asc"}},{"sort2":{"order":"desc"}}]}`, query)
+}
+
+type wildcardQuery struct {
+	field string
+	value string
+}
+
+func (w *wildcardQuery) MarshalQuery(dc queryBuilder.DataSource) (any, error) {
+	if dc.Dialect() != queryBuilder.ES {
+		return nil, fmt.Errorf("%w: wildcard on %s", queryBuilder.ErrUnsupportedQuery, dc)
+	}
+	return map[string]any{
+		"wildcard": map[string]any{w.field: map[string]string{"value": w.value}},
+	}, nil
+}
+
+type avgAgg struct {
+	name  string
+	field string
+}
+
+func (a *avgAgg) AggregationName() string {
+	return a.name
+}
+
+func (a *avgAgg) MarshalAggregation(dc queryBuilder.DataSource) (any, error) {
+	return map[string]any{"avg": map[string]string{"field": a.field}}, nil
+}
+
+func TestUserDefinedQuery(t *testing.T) {
+	t.Run("custom query type", func(t *testing.T) {
+		wildcard := &wildcardQuery{"name", "tok*"}
+		builder := queryBuilder.New().Query(
+			queryBuilder.FunctionScore(
+				queryBuilder.Bool().Must(wildcard).Should(wildcard).MustNot(wildcard),
+				[]queryBuilder.Function{{Filter: wildcard, Weight: 2}},
+			),
+		)
+
+		query, err := builder.Build(queryBuilder.ES)
+		assert.NoError(t, err)
+		assert.Equal(t, queryBuilder.Trim(`{
+			"query":{
+				"function_score":{
+					"query":{
+						"bool":{
+							"must":[{"wildcard":{"name":{"value":"tok*"}}}],
+							"must_not":[{"wildcard":{"name":{"value":"tok*"}}}],
+							"should":[{"wildcard":{"name":{"value":"tok*"}}}]
+						}
+					},
+					"functions":[
+						{"filter":{"wildcard":{"name":{"value":"tok*"}}},"weight":2}
+					]
+				}
+			}
+		}`), query)
+
+		_, err = builder.Build(queryBuilder.OpenSearch)
+		assert.ErrorIs(t, err, queryBuilder.ErrUnsupportedQuery)
+	})
+
+	t.Run("custom aggregation", func(t *testing.T) {
+		query, err := queryBuilder.New().Aggs(
+			&avgAgg{"avg_score", "score"},
+		).Build(queryBuilder.ES)
+
+		assert.NoError(t, err)
+		assert.Equal(t, `{"aggs":{"avg_score":{"avg":{"field":"score"}}}}`, query)
+	})
+
+	t.Run("raw", func(t *testing.T) {
+		query, err := queryBuilder.New().Query(
+			queryBuilder.Bool().Filter(
+				queryBuilder.Raw(json.RawMessage(`{"nested": {"path": "members", "query": {"match_all": {}}}}`)),
+			),
+		).Aggs(
+			queryBuilder.RawAgg("max_score", json.RawMessage(`{"max":{"field":"score"}}`)),
+		).Build(queryBuilder.ES)
+
+		assert.NoError(t, err)
+		assert.Equal(t, queryBuilder.Trim(`{
+			"query":{
+				"bool":{
+					"filter":[{"nested":{"path":"members","query":{"match_all":{}}}}]
+				}
+			},
+			"aggs":{
+				"max_score":{"max":{"field":"score"}}
+			}
+		}`), query)
+
+		_, err = queryBuilder.New().Query(queryBuilder.Raw(json.RawMessage(`{"term":`))).Build(queryBuilder.ES)
+		assert.Error(t, err)
+	})
+
+	t.Run("MarshalQuery", func(t *testing.T) {
+		v, err := queryBuilder.Term("target", "v").MarshalQuery(queryBuilder.ES)
+		assert.NoError(t, err)
+
+		b, _ := json.Marshal(v)
+		assert.Equal(t, `{"term":{"target":"v"}}`, string(b))
+
+		_, err = queryBuilder.Knn("embedding", queryBuilder.KnnParams{K: 1}).MarshalQuery(queryBuilder.ES)
+		assert.ErrorIs(t, err, queryBuilder.ErrUnsupportedQuery)
+	})
+}
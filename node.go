@@ -0,0 +1,145 @@
+package queryBuilder
+
+import "slices"
+
+// Node is an element of a query tree. Every Query is a Node; the
+// concrete types (*TermQuery, *BoolQuery, ...) can be inspected with a type switch.
+type Node = Query
+
+// A Visitor's Visit method is invoked for each node encountered by Walk.
+// If the result visitor w is not nil, Walk visits each of the children
+// of node with the visitor w, followed by a call of w.Visit(nil).
+type Visitor interface {
+	Visit(node Node) (w Visitor)
+}
+
+// Walk traverses a query tree in depth-first order, like go/ast.Walk.
+func Walk(node Node, v Visitor) {
+	if node == nil {
+		return
+	}
+	if v = v.Visit(node); v == nil {
+		return
+	}
+	for _, c := range children(node) {
+		Walk(c, v)
+	}
+	v.Visit(nil)
+}
+
+type inspector func(Node) bool
+
+func (f inspector) Visit(node Node) Visitor {
+	if f(node) {
+		return f
+	}
+	return nil
+}
+
+// Inspect calls f for each node of the tree; children are skipped when f returns false.
+func Inspect(node Node, f func(Node) bool) {
+	Walk(node, inspector(f))
+}
+
+func children(node Node) []Node {
+	switch n := node.(type) {
+	case *BoolQuery:
+		list := make([]Node, 0, len(n.must)+len(n.filter)+len(n.mustNot)+len(n.should))
+		list = append(list, n.must...)
+		list = append(list, n.filter...)
+		list = append(list, n.mustNot...)
+		return append(list, n.should...)
+	case *FunctionScoreQuery:
+		list := []Node{n.Query}
+		for _, f := range n.Functions {
+			list = append(list, f.Filter)
+		}
+		return list
+	case *KnnQuery:
+		return []Node{n.Params.Filter}
+	case *NeuralQuery:
+		return []Node{n.Params.Filter}
+	case *HybridQuery:
+		return n.Queries
+	}
+	return nil
+}
+
+// Rewrite returns a copy of the tree with every node replaced by fn(node).
+// Children are rewritten before their parent, so fn sees the rewritten
+// children; nodes shared with the original tree are never modified.
+// A nil result removes the node from its parent's clause list.
+func Rewrite(node Node, fn func(Node) Node) Node {
+	if node == nil {
+		return nil
+	}
+
+	switch n := node.(type) {
+	case *BoolQuery:
+		c := *n
+		c.must = rewriteAll(n.must, fn)
+		c.filter = rewriteAll(n.filter, fn)
+		c.mustNot = rewriteAll(n.mustNot, fn)
+		c.should = rewriteAll(n.should, fn)
+		node = &c
+	case *FunctionScoreQuery:
+		c := *n
+		c.Query = Rewrite(n.Query, fn)
+		c.Functions = make([]Function, 0, len(n.Functions))
+		for _, f := range n.Functions {
+			// weight-only functions have no filter to remove
+			if f.Filter == nil {
+				c.Functions = append(c.Functions, f)
+			} else if f.Filter = Rewrite(f.Filter, fn); f.Filter != nil {
+				c.Functions = append(c.Functions, f)
+			}
+		}
+		node = &c
+	case *KnnQuery:
+		c := *n
+		c.Params.Filter = Rewrite(n.Params.Filter, fn)
+		node = &c
+	case *NeuralQuery:
+		c := *n
+		c.Params.Filter = Rewrite(n.Params.Filter, fn)
+		node = &c
+	case *HybridQuery:
+		c := *n
+		c.Queries = rewriteAll(n.Queries, fn)
+		node = &c
+	}
+	return fn(node)
+}
+
+func rewriteAll(nodes []Node, fn func(Node) Node) []Node {
+	if nodes == nil {
+		return nil
+	}
+	list := make([]Node, 0, len(nodes))
+	for _, n := range nodes {
+		if r := Rewrite(n, fn); r != nil {
+			list = append(list, r)
+		}
+	}
+	return list
+}
+
+func (b *Builder) QueryNode() Node {
+	return b.query
+}
+
+func (b *Builder) PostFilterNode() Node {
+	return b.postFilter
+}
+
+// Rewrite replaces the query, the post_filter and the facet selections
+// with Rewrite(node, fn).
+func (b *Builder) Rewrite(fn func(Node) Node) *Builder {
+	b.query = Rewrite(b.query, fn)
+	b.postFilter = Rewrite(b.postFilter, fn)
+	b.facets = slices.Clone(b.facets)
+	for i, f := range b.facets {
+		b.facets[i].Selected = Rewrite(f.Selected, fn)
+	}
+	return b
+}
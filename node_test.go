@@ -0,0 +1,211 @@
+package queryBuilder_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/linksports/queryBuilder"
+	"github.com/stretchr/testify/assert"
+)
+
+func fieldOf(node queryBuilder.Node) string {
+	switch n := node.(type) {
+	case *queryBuilder.TermQuery:
+		return n.Field
+	case *queryBuilder.TermsQuery:
+		return n.Field
+	case *queryBuilder.MatchQuery:
+		return n.Field
+	case *queryBuilder.ExistsQuery:
+		return n.Field
+	case *queryBuilder.RangeQuery:
+		return n.Field
+	}
+	return ""
+}
+
+type fieldCollector struct {
+	fields []string
+	depth  int
+}
+
+func (c *fieldCollector) Visit(node queryBuilder.Node) queryBuilder.Visitor {
+	if node == nil {
+		c.depth--
+		return nil
+	}
+	if f := fieldOf(node); f != "" {
+		c.fields = append(c.fields, f)
+	}
+	c.depth++
+	return c
+}
+
+func TestNode(t *testing.T) {
+	newQuery := func() queryBuilder.Node {
+		return queryBuilder.FunctionScore(
+			queryBuilder.Bool().Must(
+				queryBuilder.Match("name", "tokyo"),
+			).Filter(
+				queryBuilder.Term("status", "active"),
+				queryBuilder.Range("age", queryBuilder.RangeParams{Gte: 20}),
+			).MustNot(
+				queryBuilder.Exists("deleted_at"),
+			),
+			[]queryBuilder.Function{
+				{Filter: queryBuilder.Exists("logo"), Weight: 3},
+			},
+		)
+	}
+
+	t.Run("walk", func(t *testing.T) {
+		c := &fieldCollector{}
+		queryBuilder.Walk(newQuery(), c)
+
+		assert.Equal(t, []string{"name", "status", "age", "deleted_at", "logo"}, c.fields)
+		assert.Equal(t, 0, c.depth)
+	})
+
+	t.Run("inspect", func(t *testing.T) {
+		var kinds []string
+		queryBuilder.Inspect(newQuery(), func(node queryBuilder.Node) bool {
+			switch node.(type) {
+			case *queryBuilder.FunctionScoreQuery:
+				kinds = append(kinds, "function_score")
+			case *queryBuilder.BoolQuery:
+				kinds = append(kinds, "bool")
+				return false
+			}
+			return true
+		})
+
+		assert.Equal(t, []string{"function_score", "bool"}, kinds)
+	})
+
+	t.Run("rewrite field names", func(t *testing.T) {
+		original := newQuery()
+		rewritten := queryBuilder.Rewrite(original, func(node queryBuilder.Node) queryBuilder.Node {
+			switch n := node.(type) {
+			case *queryBuilder.TermQuery:
+				return queryBuilder.Term(n.Field+".keyword", n.Value)
+			case *queryBuilder.ExistsQuery:
+				if strings.HasSuffix(n.Field, "_at") {
+					return nil
+				}
+			}
+			return node
+		})
+
+		query, err := queryBuilder.New().Query(rewritten).Build(queryBuilder.ES)
+		assert.NoError(t, err)
+		assert.Equal(t, queryBuilder.Trim(`{
+			"query":{
+				"function_score":{
+					"query":{
+						"bool":{
+							"must":[{"match":{"name":"tokyo"}}],
+							"filter":[
+								{"term":{"status.keyword":"active"}},
+								{"range":{"age":{"gte":20}}}
+							]
+						}
+					},
+					"functions":[
+						{"filter":{"exists":{"field":"logo"}},"weight":3}
+					]
+				}
+			}
+		}`), query)
+
+		query, err = queryBuilder.New().Query(original).Build(queryBuilder.ES)
+		assert.NoError(t, err)
+		assert.Contains(t, query, `{"term":{"status":"active"}}`)
+		assert.Contains(t, query, `"must_not":[{"exists":{"field":"deleted_at"}}]`)
+	})
+
+	t.Run("inject tenant filter", func(t *testing.T) {
+		builder := queryBuilder.New().Query(queryBuilder.Match("name", "tokyo"))
+		builder.Query(
+			queryBuilder.Bool().Must(builder.QueryNode()).Filter(queryBuilder.Term("tenant_id", 42)),
+		)
+
+		query, err := builder.Build(queryBuilder.ES)
+		assert.NoError(t, err)
+		assert.Equal(t, queryBuilder.Trim(`{
+			"query":{
+				"bool":{
+					"must":[{"match":{"name":"tokyo"}}],
+					"filter":[{"term":{"tenant_id":42}}]
+				}
+			}
+		}`), query)
+	})
+
+	t.Run("bool clauses", func(t *testing.T) {
+		var mustNot []string
+		queryBuilder.Inspect(newQuery(), func(node queryBuilder.Node) bool {
+			if b, ok := node.(*queryBuilder.BoolQuery); ok {
+				for _, q := range b.Clauses().MustNot {
+					mustNot = append(mustNot, fieldOf(q))
+				}
+			}
+			return true
+		})
+		assert.Equal(t, []string{"deleted_at"}, mustNot)
+
+		clauses := queryBuilder.Bool().Should(queryBuilder.Term("a", 1)).MinimumShouldMatch(1).Name("q").Clauses()
+		assert.Len(t, clauses.Should, 1)
+		assert.Empty(t, clauses.Must)
+		assert.Equal(t, 1, clauses.MinimumShouldMatch)
+		assert.Equal(t, "q", clauses.Name)
+	})
+
+	t.Run("identity rewrite keeps weight-only functions", func(t *testing.T) {
+		original := queryBuilder.FunctionScore(queryBuilder.MatchAll(), []queryBuilder.Function{
+			{Weight: 2},
+			{Filter: queryBuilder.Exists("logo"), Weight: 3},
+		})
+		rewritten := queryBuilder.Rewrite(original, func(node queryBuilder.Node) queryBuilder.Node { return node })
+
+		query, err := queryBuilder.New().Query(rewritten).Build(queryBuilder.ES)
+		assert.NoError(t, err)
+		assert.Equal(t, `{"query":{"function_score":{"query":{"match_all":{}},"functions":[{"weight":2},{"filter":{"exists":{"field":"logo"}},"weight":3}]}}}`, query)
+	})
+
+	t.Run("builder rewrite", func(t *testing.T) {
+		query, err := queryBuilder.New().Query(
+			queryBuilder.Bool().Should(
+				queryBuilder.Terms("team", []string{"a"}),
+				queryBuilder.Match("team", "b"),
+			),
+		).Rewrite(func(node queryBuilder.Node) queryBuilder.Node {
+			switch n := node.(type) {
+			case *queryBuilder.TermsQuery:
+				n.Field = "team_id"
+			case *queryBuilder.MatchQuery:
+				n.Field = "team_name"
+			}
+			return node
+		}).Build(queryBuilder.ES)
+
+		assert.NoError(t, err)
+		assert.Equal(t, `{"query":{"bool":{"should":[{"terms":{"team_id":["a"]}},{"match":{"team_name":"b"}}]}}}`, query)
+	})
+
+	t.Run("builder rewrite with post_filter", func(t *testing.T) {
+		builder := queryBuilder.New().
+			Query(queryBuilder.Term("team", "a")).
+			PostFilter(queryBuilder.Term("team", "b")).
+			Rewrite(func(node queryBuilder.Node) queryBuilder.Node {
+				if n, ok := node.(*queryBuilder.TermQuery); ok {
+					return queryBuilder.Term(n.Field+"_id", n.Value)
+				}
+				return node
+			})
+
+		query, err := builder.Build(queryBuilder.ES)
+		assert.NoError(t, err)
+		assert.Equal(t, `{"query":{"term":{"team_id":"a"}},"post_filter":{"term":{"team_id":"b"}}}`, query)
+		assert.Equal(t, "team_id", fieldOf(builder.PostFilterNode()))
+	})
+}
@@ -0,0 +1,394 @@
+package queryBuilder
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+)
+
+// Parse rebuilds a Builder from a search request body. Clauses the package
+// has no node for, or cannot render identically, are kept verbatim, so
+// Parse(dc, x).Build(dc) renders x again; top-level sections kept verbatim
+// come last.
+func Parse(dc DataSource, data []byte) (*Builder, error) {
+	if d := dc.Dialect(); d != ES && d != OpenSearch {
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedDataSource, dc)
+	}
+
+	var body map[string]json.RawMessage
+	if err := unmarshal(data, &body); err != nil {
+		return nil, err
+	}
+
+	b := New()
+	for key, value := range body {
+		if !b.parseSection(key, value) {
+			if b.extra == nil {
+				b.extra = map[string]json.RawMessage{}
+			}
+			b.extra[key] = value
+		}
+	}
+	return b, nil
+}
+
+func unmarshal(data []byte, v any) error {
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+	return d.Decode(v)
+}
+
+func (b *Builder) parseSection(key string, value json.RawMessage) bool {
+	switch key {
+	case "size", "from":
+		// zero is omitted when rendering, so it stays in b.extra
+		var n int
+		if unmarshal(value, &n) != nil || n == 0 {
+			return false
+		}
+		if key == "size" {
+			b.size = n
+		} else {
+			b.from = n
+		}
+		return true
+	case "query":
+		b.query = parseQuery(value)
+		return true
+	case "post_filter":
+		b.postFilter = parseQuery(value)
+		return true
+	case "sort":
+		var sort []json.RawMessage
+		if unmarshal(value, &sort) != nil {
+			return false
+		}
+		for _, s := range sort {
+			if parsed, ok := parseSort(s); ok {
+				b.Sort(parsed)
+			} else {
+				b.sort = append(b.sort, rawSort(s))
+			}
+		}
+		return true
+	case "_source":
+		var disabled bool
+		if unmarshal(value, &disabled) == nil && !disabled {
+			b.DisableSource()
+			return true
+		}
+		var filter struct {
+			Includes []string `json:"includes"`
+			Excludes []string `json:"excludes"`
+		}
+		var m map[string]json.RawMessage
+		if unmarshal(value, &m) == nil && onlyKeys(m, "includes", "excludes") &&
+			unmarshal(value, &filter) == nil && len(filter.Excludes) > 0 {
+			b.SourceIncludes(filter.Includes...).SourceExcludes(filter.Excludes...)
+			return true
+		}
+		return unmarshal(value, &b.source) == nil
+	case "search_after":
+		return unmarshal(value, &b.searchAfter) == nil
+	case "aggs":
+		aggs, ok := parseAggs(value)
+		b.aggs = aggs
+		return ok
+	case "track_total_hits":
+		var v any
+		if unmarshal(value, &v) != nil {
+			return false
+		}
+		switch v.(type) {
+		case bool, json.Number:
+			b.totalHits = v
+			return true
+		}
+	}
+	return false
+}
+
+func parseSort(data json.RawMessage) (Sort, bool) {
+	field, value, ok := singleField(data)
+	var o map[string]string
+	if !ok || unmarshal(value, &o) != nil || len(o) != 1 || o["order"] != "asc" && o["order"] != "desc" {
+		return Sort{}, false
+	}
+	return Sort{field, SortOrder(o["order"])}, true
+}
+
+// rawSort is a sort entry Parse has no type for.
+type rawSort json.RawMessage
+
+func (r rawSort) sortClause(dc DataSource) (any, error) {
+	return json.RawMessage(r), nil
+}
+
+func singleField(data json.RawMessage) (string, json.RawMessage, bool) {
+	var m map[string]json.RawMessage
+	if unmarshal(data, &m) != nil || len(m) != 1 {
+		return "", nil, false
+	}
+	for k, v := range m {
+		return k, v, true
+	}
+	return "", nil, false
+}
+
+func onlyKeys(m map[string]json.RawMessage, keys ...string) bool {
+	for k := range m {
+		if !slices.Contains(keys, k) {
+			return false
+		}
+	}
+	return true
+}
+
+func parseQuery(data json.RawMessage) Query {
+	if kind, body, ok := singleField(data); ok {
+		if q := parseClause(kind, body); q != nil {
+			return q
+		}
+	}
+	return Raw(data)
+}
+
+func parseClause(kind string, body json.RawMessage) Query {
+	switch kind {
+	case "match_all":
+		var m map[string]json.RawMessage
+		if unmarshal(body, &m) == nil && len(m) == 0 {
+			return MatchAll()
+		}
+	case "match", "match_phrase", "prefix":
+		field, value, ok := singleField(body)
+		var v string
+		if !ok || unmarshal(value, &v) != nil {
+			return nil
+		}
+		switch kind {
+		case "match":
+			return Match(field, v)
+		case "match_phrase":
+			return MatchPhrase(field, []string{v})
+		default:
+			return Prefix(field, v)
+		}
+	case "term":
+		field, value, ok := singleField(body)
+		var v any
+		if !ok || unmarshal(value, &v) != nil {
+			return nil
+		}
+		switch v.(type) {
+		case string, json.Number, bool:
+			return Term(field, v)
+		}
+	case "terms":
+		field, value, ok := singleField(body)
+		var v []any
+		if !ok || unmarshal(value, &v) != nil || len(v) == 0 {
+			return nil
+		}
+		return Terms(field, v)
+	case "exists":
+		var e map[string]string
+		if unmarshal(body, &e) == nil && len(e) == 1 && e["field"] != "" {
+			return Exists(e["field"])
+		}
+	case "range":
+		field, value, ok := singleField(body)
+		var m map[string]json.RawMessage
+		if !ok || unmarshal(value, &m) != nil || !onlyKeys(m, "gte", "gt", "lte", "lt") {
+			return nil
+		}
+		var params RangeParams
+		if unmarshal(value, &params) != nil {
+			return nil
+		}
+		return Range(field, params)
+	case "multi_match":
+		var m map[string]json.RawMessage
+		if unmarshal(body, &m) != nil || !onlyKeys(m, "fields", "query") || m["fields"] == nil || m["query"] == nil {
+			return nil
+		}
+		var params struct {
+			Fields []string `json:"fields"`
+			Query  any      `json:"query"`
+		}
+		if unmarshal(body, &params) != nil {
+			return nil
+		}
+		return MultiMatch(MultiMatchParams{Query: params.Query, Fields: params.Fields})
+	case "bool":
+		return parseBool(body)
+	case "function_score":
+		return parseFunctionScore(body)
+	}
+	return nil
+}
+
+func parseBool(body json.RawMessage) Query {
+	var m map[string]json.RawMessage
+	if unmarshal(body, &m) != nil || !onlyKeys(m, "must", "filter", "must_not", "should", "minimum_should_match", "boost", "_name") {
+		return nil
+	}
+
+	var conditions struct {
+		Must               []json.RawMessage `json:"must"`
+		Filter             []json.RawMessage `json:"filter"`
+		MustNot            []json.RawMessage `json:"must_not"`
+		Should             []json.RawMessage `json:"should"`
+		MinimumShouldMatch any               `json:"minimum_should_match"`
+		Boost              float32           `json:"boost"`
+		Name               string            `json:"_name"`
+	}
+	if unmarshal(body, &conditions) != nil {
+		return nil
+	}
+
+	q := Bool()
+	for _, c := range conditions.Must {
+		q.Must(parseQuery(c))
+	}
+	for _, c := range conditions.Filter {
+		q.Filter(parseQuery(c))
+	}
+	for _, c := range conditions.MustNot {
+		q.MustNot(parseQuery(c))
+	}
+	for _, c := range conditions.Should {
+		q.Should(parseQuery(c))
+	}
+	return q.MinimumShouldMatch(conditions.MinimumShouldMatch).
+		Boost(conditions.Boost).
+		Name(conditions.Name)
+}
+
+func parseFunctionScore(body json.RawMessage) Query {
+	var m map[string]json.RawMessage
+	if unmarshal(body, &m) != nil || !onlyKeys(m, "query", "functions") || m["query"] == nil {
+		return nil
+	}
+
+	var raw []json.RawMessage
+	if m["functions"] != nil && unmarshal(m["functions"], &raw) != nil {
+		return nil
+	}
+	functions := make([]Function, len(raw))
+	for i, f := range raw {
+		var fm map[string]json.RawMessage
+		if unmarshal(f, &fm) != nil || !onlyKeys(fm, "filter", "weight") || fm["filter"] == nil {
+			return nil
+		}
+		var weight float32
+		if unmarshal(fm["weight"], &weight) != nil {
+			return nil
+		}
+		functions[i] = Function{Filter: parseQuery(fm["filter"]), Weight: weight}
+	}
+	return FunctionScore(parseQuery(m["query"]), functions)
+}
+
+func parseAggs(data json.RawMessage) ([]Aggregation, bool) {
+	var aggs map[string]json.RawMessage
+	if unmarshal(data, &aggs) != nil {
+		return nil, false
+	}
+	names := make([]string, 0, len(aggs))
+	for name, agg := range aggs {
+		var body map[string]json.RawMessage
+		if unmarshal(agg, &body) != nil {
+			return nil, false
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	list := make([]Aggregation, len(names))
+	for i, name := range names {
+		list[i] = parseAggregation(name, aggs[name])
+	}
+	return list, true
+}
+
+func parseAggregation(name string, data json.RawMessage) Aggregation {
+	var body, m map[string]json.RawMessage
+	var params struct {
+		Field string            `json:"field"`
+		Size  int               `json:"size"`
+		Order map[string]string `json:"order"`
+	}
+	if unmarshal(data, &body) != nil || body["terms"] == nil || !onlyKeys(body, "terms", "aggs") ||
+		unmarshal(body["terms"], &m) != nil || !onlyKeys(m, "field", "size", "order") ||
+		unmarshal(body["terms"], &params) != nil || params.Field == "" {
+		return RawAgg(name, data)
+	}
+	// TermsAgg drops a zero size and renders _term as _key; keep those as written.
+	if m["size"] != nil && params.Size <= 0 {
+		return RawAgg(name, data)
+	}
+	if m["order"] != nil {
+		if len(params.Order) != 1 {
+			return RawAgg(name, data)
+		}
+		for key, order := range params.Order {
+			if key == "_term" || (order != "asc" && order != "desc") {
+				return RawAgg(name, data)
+			}
+		}
+	}
+
+	agg := TermsAgg(AggregateParams{
+		Name:      name,
+		FieldName: params.Field,
+		Order:     params.Order,
+		Size:      params.Size,
+	})
+	if body["aggs"] != nil {
+		subAggs, ok := parseAggs(body["aggs"])
+		if !ok {
+			return RawAgg(name, data)
+		}
+		agg.SubAggs(subAggs...)
+	}
+	return agg
+}
+
+// appendExtra adds the sections Parse kept verbatim to a rendered body,
+// unless the builder rendered that section itself.
+func appendExtra(body []byte, extra map[string]json.RawMessage) ([]byte, error) {
+	if len(extra) == 0 {
+		return body, nil
+	}
+
+	var rendered map[string]json.RawMessage
+	if err := json.Unmarshal(body, &rendered); err != nil {
+		return nil, err
+	}
+	keys := make([]string, 0, len(extra))
+	for k := range extra {
+		if _, ok := rendered[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	var buf bytes.Buffer
+	buf.Write(bytes.TrimSuffix(body, []byte("}")))
+	for _, k := range keys {
+		if buf.Len() > 1 {
+			buf.WriteByte(',')
+		}
+		key, _ := json.Marshal(k)
+		buf.Write(key)
+		buf.WriteByte(':')
+		if err := json.Compact(&buf, extra[k]); err != nil {
+			return nil, err
+		}
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,132 @@
+package queryBuilder_test
+
+import (
+	"testing"
+
+	"github.com/linksports/queryBuilder"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	t.Run("round trip", func(t *testing.T) {
+		for name, body := range map[string]string{
+			"match_all":             `{"query":{"match_all":{}}}`,
+			"match":                 `{"query":{"match":{"target":"v"}}}`,
+			"match_phrase":          `{"query":{"match_phrase":{"target":"red blue green"}}}`,
+			"term(string)":          `{"query":{"term":{"target.keyword":"v"}}}`,
+			"term(number)":          `{"query":{"term":{"sport_id":1700000000000}}}`,
+			"terms":                 `{"query":{"terms":{"target.keyword":[1,2.5,"3"]}}}`,
+			"prefix":                `{"query":{"prefix":{"target":"v"}}}`,
+			"exists":                `{"query":{"exists":{"field":"target"}}}`,
+			"range":                 `{"query":{"range":{"target":{"gte":10,"lt":"hanako"}}}}`,
+			"multi_match":           `{"query":{"multi_match":{"fields":["name^3","city"],"query":"tokyo"}}}`,
+			"search_after":          `{"search_after":["0","1"]}`,
+			"search_after(typed)":   `{"search_after":[1700000000000,9007199254740993,null,"x"]}`,
+			"_source":               `{"_source":["a","b"]}`,
+			"_source(false)":        `{"_source":false}`,
+			"_source(excludes)":     `{"_source":{"excludes":["body"],"includes":["a*"]}}`,
+			"sort":                  `{"sort":[{"sort1":{"order":"asc"}},{"sort2":{"order":"desc"}}]}`,
+			"size+from":             `{"size":20,"from":5,"query":{"match_all":{}}}`,
+			"post_filter":           `{"query":{"match":{"name":"tokyo"}},"post_filter":{"term":{"color":"red"}}}`,
+			"size(zero)":            `{"aggs":{"a":{"terms":{"field":"sport_id"}}},"size":0}`,
+			"from(zero)":            `{"size":10,"from":0}`,
+			"terms(empty)":          `{"query":{"terms":{"f":[]}}}`,
+			"multi_match(query)":    `{"query":{"multi_match":{"query":"tokyo"}}}`,
+			"function_score(query)": `{"query":{"function_score":{"query":{"match_all":{}}}}}`,
+			"track_total":           `{"track_total_hits":10000}`,
+			"aggs_term":             `{"aggs":{"a":{"terms":{"field":"sport_id","order":{"_count":"desc"},"size":10}},"b":{"terms":{"field":"league"}}}}`,
+			"aggs_term(size zero)":  `{"aggs":{"a":{"terms":{"field":"x","size":0}}}}`,
+			"aggs_term(_term)":      `{"aggs":{"a":{"terms":{"field":"x","order":{"_term":"asc"}}}}}`,
+			"aggs_term(no field)":   `{"aggs":{"a":{"terms":{"size":5}}}}`,
+			"sub_aggs":              `{"aggs":{"leagues":{"aggs":{"avg_score":{"avg":{"field":"score"}},"teams":{"terms":{"field":"team_id","order":{"avg_score":"desc"}}}},"terms":{"field":"league_id"}}}}`,
+			"bool":                  `{"query":{"bool":{"must":[{"match":{"name":"tokyo"}}],"filter":[{"term":{"status":"active"}}],"must_not":[{"exists":{"field":"deleted_at"}}],"should":[{"term":{"a":1}},{"term":{"b":2}}],"minimum_should_match":"3<90%","boost":1.5,"_name":"q"}}}`,
+			"function_score": queryBuilder.Trim(`{
+				"size":20,
+				"from":5,
+				"query":{
+					"function_score":{
+						"query":{
+							"bool":{
+								"must":[{"multi_match":{"fields":["name^3","description","city"],"query":"teamName1 teamName2 tokyo"}}],
+								"must_not":[{"exists":{"field":"deleted_at"}}]
+							}
+						},
+						"functions":[
+							{"filter":{"exists":{"field":"logo"}},"weight":3},
+							{"filter":{"exists":{"field":"photo"}},"weight":1.5}
+						]
+					}
+				},
+				"aggs":{
+					"sportID_term":{"terms":{"field":"sport_id","order":{"_count":"desc"},"size":10}}
+				}
+			}`),
+		} {
+			t.Run(name, func(t *testing.T) {
+				builder, err := queryBuilder.Parse(queryBuilder.ES, []byte(body))
+				assert.NoError(t, err)
+
+				query, err := builder.Build(queryBuilder.ES)
+				assert.NoError(t, err)
+				assert.Equal(t, body, query)
+			})
+		}
+	})
+
+	t.Run("unknown clauses are kept", func(t *testing.T) {
+		body := queryBuilder.Trim(`{
+			"query":{
+				"bool":{
+					"must":[
+						{"nested":{"path":"members","query":{"term":{"members.name":"taro"}}}},
+						{"term":{"name":{"value":"tokyo","boost":2}}}
+					]
+				}
+			},
+			"sort":["_score",{"name":{"order":"asc","missing":"_last"}}],
+			"aggs":{
+				"avg_score":{"avg":{"field":"score"}}
+			},
+			"highlight":{"fields":{"name":{}}},
+			"_source":false
+		}`)
+
+		builder, err := queryBuilder.Parse(queryBuilder.ES, []byte(body))
+		assert.NoError(t, err)
+
+		query, err := builder.Build(queryBuilder.ES)
+		assert.NoError(t, err)
+		assert.JSONEq(t, body, query)
+	})
+
+	t.Run("modify parsed query", func(t *testing.T) {
+		builder, err := queryBuilder.Parse(queryBuilder.ES, []byte(`{
+			"query": {"term": {"target.keyword": "v"}},
+			"sort": [{"sort1": {"order": "asc"}}],
+			"highlight": {"fields": {"name": {}}}
+		}`))
+		assert.NoError(t, err)
+
+		query, err := builder.Size(10).Sort(queryBuilder.Sort{"sort2", "desc"}).Build(queryBuilder.ES)
+		assert.NoError(t, err)
+		assert.Equal(t, queryBuilder.Trim(`{
+			"size":10,
+			"query":{
+				"term":{"target.keyword":"v"}
+			},
+			"sort":[
+				{"sort1":{"order":"asc"}},
+				{"sort2":{"order":"desc"}}
+			],
+			"highlight":{"fields":{"name":{}}}
+		}`), query)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		_, err := queryBuilder.Parse(queryBuilder.ES, []byte(`{"query":`))
+		assert.Error(t, err)
+
+		_, err = queryBuilder.Parse(queryBuilder.DataSource("Solr"), []byte(`{}`))
+		assert.ErrorIs(t, err, queryBuilder.ErrUnsupportedDataSource)
+	})
+}
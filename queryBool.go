@@ -1,48 +1,112 @@
 package queryBuilder
 
-type boolQuery struct {
-	bool boolConditions
+import (
+	"errors"
+	"slices"
+)
+
+type BoolQuery struct {
+	must               []Query
+	filter             []Query
+	mustNot            []Query
+	should             []Query
+	minimumShouldMatch any
+	boost              float32
+	name               string
 }
 
-func (q boolQuery) generate() any {
+func (q *BoolQuery) MarshalQuery(dc DataSource) (any, error) {
+	var errs [4]error
+	conditions := boolConditions{
+		MinimumShouldMatch: q.minimumShouldMatch,
+		Boost:              q.boost,
+		Name:               q.name,
+	}
+	conditions.Must, errs[0] = renderAll(q.must, dc, "BoolQuery.Must", "g")
+	conditions.Filter, errs[1] = renderAll(q.filter, dc, "BoolQuery.Filter", "g")
+	conditions.MustNot, errs[2] = renderAll(q.mustNot, dc, "BoolQuery.MustNot", "g")
+	conditions.Should, errs[3] = renderAll(q.should, dc, "BoolQuery.Should", "g")
+	if err := errors.Join(errs[:]...); err != nil {
+		return nil, err
+	}
+
 	return struct {
 		Bool boolConditions `json:"bool,omitempty"`
-	}{q.bool}
+	}{conditions}, nil
 }
 
 type boolConditions struct {
-	Must    []any `json:"must,omitempty"`
-	MustNot []any `json:"must_not,omitempty"`
-	Should  []any `json:"should,omitempty"`
+	Must               []any   `json:"must,omitempty"`
+	Filter             []any   `json:"filter,omitempty"`
+	MustNot            []any   `json:"must_not,omitempty"`
+	Should             []any   `json:"should,omitempty"`
+	MinimumShouldMatch any     `json:"minimum_should_match,omitempty"`
+	Boost              float32 `json:"boost,omitempty"`
+	Name               string  `json:"_name,omitempty"`
 }
 
-func Bool() *boolQuery {
-	return &boolQuery{boolConditions{}}
+func Bool() *BoolQuery {
+	return &BoolQuery{}
 }
 
-func (q *boolQuery) Must(g ...Generatable) *boolQuery {
-	must := make([]any, len(g))
-	for i, c := range g {
-		must[i] = c.generate()
-	}
-	q.bool.Must = append(q.bool.Must, must...)
+func (q *BoolQuery) Must(g ...Query) *BoolQuery {
+	q.must = append(q.must, g...)
 	return q
 }
 
-func (q *boolQuery) MustNot(g ...Generatable) *boolQuery {
-	mustNot := make([]any, len(g))
-	for i, c := range g {
-		mustNot[i] = c.generate()
-	}
-	q.bool.MustNot = append(q.bool.MustNot, mustNot...)
+func (q *BoolQuery) Filter(g ...Query) *BoolQuery {
+	q.filter = append(q.filter, g...)
 	return q
 }
 
-func (q *boolQuery) Should(g ...Generatable) *boolQuery {
-	should := make([]any, len(g))
-	for i, c := range g {
-		should[i] = c.generate()
-	}
-	q.bool.Should = append(q.bool.Should, should...)
+func (q *BoolQuery) MustNot(g ...Query) *BoolQuery {
+	q.mustNot = append(q.mustNot, g...)
+	return q
+}
+
+func (q *BoolQuery) Should(g ...Query) *BoolQuery {
+	q.should = append(q.should, g...)
+	return q
+}
+
+// value accepts an int (e.g. 2, -1), a percentage (e.g. "75%", "-25%")
+// or a combination expression (e.g. "3<90%", "2<-25% 9<-3").
+func (q *BoolQuery) MinimumShouldMatch(value any) *BoolQuery {
+	q.minimumShouldMatch = value
+	return q
+}
+
+func (q *BoolQuery) Boost(value float32) *BoolQuery {
+	q.boost = value
+	return q
+}
+
+func (q *BoolQuery) Name(value string) *BoolQuery {
+	q.name = value
 	return q
 }
+
+// BoolClauses is what a BoolQuery holds, as returned by BoolQuery.Clauses.
+type BoolClauses struct {
+	Must               []Query
+	Filter             []Query
+	MustNot            []Query
+	Should             []Query
+	MinimumShouldMatch any
+	Boost              float32
+	Name               string
+}
+
+// Clauses returns the clauses and options of q. Slices are copies; changing
+// them does not change q.
+func (q *BoolQuery) Clauses() BoolClauses {
+	return BoolClauses{
+		Must:               slices.Clone(q.must),
+		Filter:             slices.Clone(q.filter),
+		MustNot:            slices.Clone(q.mustNot),
+		Should:             slices.Clone(q.should),
+		MinimumShouldMatch: q.minimumShouldMatch,
+		Boost:              q.boost,
+		Name:               q.name,
+	}
+}
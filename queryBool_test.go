@@ -137,6 +137,89 @@ func TestQueryBool(t *testing.T) {
 				}
 			}`), query)
 		})
+
+		t.Run("bool > filter", func(t *testing.T) {
+			builder := queryBuilder.New()
+			query, err := builder.Query(
+				queryBuilder.Bool().Must(
+					queryBuilder.Match("name", "tokyo"),
+				).Filter(
+					queryBuilder.Term("status.keyword", "active"),
+					queryBuilder.Range("age", queryBuilder.RangeParams{Gte: 20}),
+				),
+			).Build(queryBuilder.ES)
+
+			assert.NoError(t, err)
+			assert.Equal(t, queryBuilder.Trim(`{
+				"query":{
+					"bool":{
+						"must":[
+							{"match":{"name":"tokyo"}}
+						],
+						"filter":[
+							{"term":{"status.keyword":"active"}},
+							{"range":{"age":{"gte":20}}}
+						]
+					}
+				}
+			}`), query)
+		})
+
+		t.Run("bool > minimum_should_match", func(t *testing.T) {
+			for _, c := range []struct {
+				value    any
+				expected string
+			}{
+				{2, `2`},
+				{-1, `-1`},
+				{"75%", `"75%"`},
+				{"3<90%", `"3<90%"`},
+				{"2<-25% 9<-3", `"2<-25% 9<-3"`},
+			} {
+				builder := queryBuilder.New()
+				query, err := builder.Query(
+					queryBuilder.Bool().Should(
+						queryBuilder.Term("a", "1"),
+						queryBuilder.Term("b", "2"),
+					).MinimumShouldMatch(c.value),
+				).Build(queryBuilder.ES)
+
+				assert.NoError(t, err)
+				assert.Equal(t, queryBuilder.Trim(`{
+					"query":{
+						"bool":{
+							"should":[
+								{"term":{"a":"1"}},
+								{"term":{"b":"2"}}
+							],
+							"minimum_should_match":`+c.expected+`
+						}
+					}
+				}`), query)
+			}
+		})
+
+		t.Run("bool > boost+_name", func(t *testing.T) {
+			builder := queryBuilder.New()
+			query, err := builder.Query(
+				queryBuilder.Bool().Filter(
+					queryBuilder.Exists("logo"),
+				).Boost(1.5).Name("has_logo"),
+			).Build(queryBuilder.ES)
+
+			assert.NoError(t, err)
+			assert.Equal(t, queryBuilder.Trim(`{
+				"query":{
+					"bool":{
+						"filter":[
+							{"exists":{"field":"logo"}}
+						],
+						"boost":1.5,
+						"_name":"has_logo"
+					}
+				}
+			}`), query)
+		})
 	})
 
 	t.Run("building query", func(t *testing.T) {
@@ -0,0 +1,266 @@
+package queryBuilder
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+)
+
+// GeoPoint is a location given as Lat/Lon, a Geohash or a WKT point.
+type GeoPoint struct {
+	Lat     float64
+	Lon     float64
+	Geohash string
+	WKT     string // e.g. "POINT (139.69 35.68)"
+}
+
+func LatLon(lat, lon float64) GeoPoint {
+	return GeoPoint{Lat: lat, Lon: lon}
+}
+
+func Geohash(hash string) GeoPoint {
+	return GeoPoint{Geohash: hash}
+}
+
+func WKTPoint(wkt string) GeoPoint {
+	return GeoPoint{WKT: wkt}
+}
+
+func (p GeoPoint) latLon() bool {
+	return p.Geohash == "" && p.WKT == ""
+}
+
+func (p GeoPoint) generate(call, arg string) (any, error) {
+	switch {
+	case p.Geohash != "" && p.WKT != "":
+		return nil, &BuildError{call, arg, fmt.Errorf("%w: both Geohash and WKT are set", ErrInvalidValue)}
+	case p.Geohash != "":
+		return p.Geohash, nil
+	case p.WKT != "":
+		return p.WKT, nil
+	case p.Lat < -90 || p.Lat > 90 || p.Lon < -180 || p.Lon > 180:
+		return nil, &BuildError{call, arg, fmt.Errorf("%w: lat %v, lon %v out of range", ErrInvalidValue, p.Lat, p.Lon)}
+	}
+	return map[string]float64{"lat": p.Lat, "lon": p.Lon}, nil
+}
+
+type DistanceUnit string
+
+const (
+	Millimeters   DistanceUnit = "mm"
+	Centimeters   DistanceUnit = "cm"
+	Meters        DistanceUnit = "m"
+	Kilometers    DistanceUnit = "km"
+	Inches        DistanceUnit = "in"
+	Feet          DistanceUnit = "ft"
+	Yards         DistanceUnit = "yd"
+	Miles         DistanceUnit = "mi"
+	NauticalMiles DistanceUnit = "nmi"
+)
+
+var distanceUnits = []DistanceUnit{Millimeters, Centimeters, Meters, Kilometers, Inches, Feet, Yards, Miles, NauticalMiles}
+
+type Distance struct {
+	Value float64
+	Unit  DistanceUnit
+}
+
+func (d Distance) String() string {
+	return strconv.FormatFloat(d.Value, 'f', -1, 64) + string(d.Unit)
+}
+
+func (d Distance) generate(call, arg string) (string, error) {
+	if d.Value <= 0 {
+		return "", &BuildError{call, arg, fmt.Errorf("%w: distance must be positive", ErrInvalidValue)}
+	}
+	if !slices.Contains(distanceUnits, d.Unit) {
+		return "", &BuildError{call, arg, fmt.Errorf("%w: unknown unit %q", ErrInvalidValue, d.Unit)}
+	}
+	return d.String(), nil
+}
+
+type GeoDistanceQuery struct {
+	Field        string
+	Point        GeoPoint
+	Distance     Distance
+	DistanceType string // arc or plane
+}
+
+func (g *GeoDistanceQuery) MarshalQuery(dc DataSource) (any, error) {
+	if err := requireField("GeoDistance", g.Field); err != nil {
+		return nil, err
+	}
+	point, err := g.Point.generate("GeoDistance", "point")
+	if err != nil {
+		return nil, err
+	}
+	distance, err := g.Distance.generate("GeoDistance", "distance")
+	if err != nil {
+		return nil, err
+	}
+
+	body := map[string]any{"distance": distance, g.Field: point}
+	setIf(body, "distance_type", g.DistanceType, g.DistanceType != "")
+	return map[string]any{"geo_distance": body}, nil
+}
+
+// GeoDistance matches geo_point values within distance of point.
+func GeoDistance(field string, point GeoPoint, distance Distance) Query {
+	return &GeoDistanceQuery{Field: field, Point: point, Distance: distance}
+}
+
+type GeoBoundingBoxQuery struct {
+	Field       string
+	TopLeft     GeoPoint
+	BottomRight GeoPoint
+}
+
+func (g *GeoBoundingBoxQuery) MarshalQuery(dc DataSource) (any, error) {
+	if err := requireField("GeoBoundingBox", g.Field); err != nil {
+		return nil, err
+	}
+	if g.TopLeft.latLon() && g.BottomRight.latLon() && g.TopLeft.Lat < g.BottomRight.Lat {
+		return nil, &BuildError{"GeoBoundingBox", "topLeft", fmt.Errorf("%w: top %v is below bottom %v", ErrInvalidValue, g.TopLeft.Lat, g.BottomRight.Lat)}
+	}
+	topLeft, err := g.TopLeft.generate("GeoBoundingBox", "topLeft")
+	if err != nil {
+		return nil, err
+	}
+	bottomRight, err := g.BottomRight.generate("GeoBoundingBox", "bottomRight")
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]any{
+		"geo_bounding_box": map[string]any{
+			g.Field: map[string]any{"top_left": topLeft, "bottom_right": bottomRight},
+		},
+	}, nil
+}
+
+func GeoBoundingBox(field string, topLeft, bottomRight GeoPoint) Query {
+	return &GeoBoundingBoxQuery{field, topLeft, bottomRight}
+}
+
+type GeoPolygonQuery struct {
+	Field  string
+	Points []GeoPoint
+}
+
+func (g *GeoPolygonQuery) MarshalQuery(dc DataSource) (any, error) {
+	if err := requireField("GeoPolygon", g.Field); err != nil {
+		return nil, err
+	}
+	if len(g.Points) < 3 {
+		return nil, &BuildError{"GeoPolygon", "points", fmt.Errorf("%w: a polygon needs at least 3 points", ErrEmptyValues)}
+	}
+	points := make([]any, len(g.Points))
+	for i, p := range g.Points {
+		point, err := p.generate("GeoPolygon", fmt.Sprintf("points[%d]", i))
+		if err != nil {
+			return nil, err
+		}
+		points[i] = point
+	}
+
+	return map[string]any{
+		"geo_polygon": map[string]any{
+			g.Field: map[string]any{"points": points},
+		},
+	}, nil
+}
+
+func (g *GeoPolygonQuery) deprecations(dc DataSource) []string {
+	if v, ok := dc.esVersion(); ok && v.atLeast(7, 12) {
+		return []string{fmt.Sprintf("query.geo_polygon.%s: geo_polygon is deprecated since Elasticsearch 7.12, use geo_shape", g.Field)}
+	}
+	return nil
+}
+
+// GeoPolygon matches geo_point values inside the polygon through points.
+func GeoPolygon(field string, points []GeoPoint) Query {
+	return &GeoPolygonQuery{field, points}
+}
+
+type GeoShapeQuery struct {
+	Field        string
+	Shape        any // a GeoJSON object or a WKT string
+	IndexedShape *IndexedShape
+	Relation     string // intersects, disjoint, within or contains
+}
+
+// IndexedShape refers to a shape stored in another document.
+type IndexedShape struct {
+	Index string `json:"index"`
+	ID    string `json:"id"`
+	Path  string `json:"path,omitempty"`
+}
+
+var geoShapeRelations = []string{"", "intersects", "disjoint", "within", "contains"}
+
+func (g *GeoShapeQuery) MarshalQuery(dc DataSource) (any, error) {
+	if err := requireField("GeoShape", g.Field); err != nil {
+		return nil, err
+	}
+	if (g.Shape == nil) == (g.IndexedShape == nil) {
+		return nil, &BuildError{"GeoShape", "shape", fmt.Errorf("%w: exactly one of Shape and IndexedShape must be set", ErrInvalidValue)}
+	}
+	if !slices.Contains(geoShapeRelations, g.Relation) {
+		return nil, &BuildError{"GeoShape", "relation", fmt.Errorf("%w: %q", ErrInvalidValue, g.Relation)}
+	}
+
+	body := map[string]any{}
+	setIf(body, "shape", g.Shape, g.Shape != nil)
+	setIf(body, "indexed_shape", g.IndexedShape, g.IndexedShape != nil)
+	setIf(body, "relation", g.Relation, g.Relation != "")
+	return map[string]any{
+		"geo_shape": map[string]any{g.Field: body},
+	}, nil
+}
+
+func GeoShape(field string, shape any, relation string) Query {
+	return &GeoShapeQuery{Field: field, Shape: shape, Relation: relation}
+}
+
+// GeoDistanceSort orders hits by their distance from Points.
+type GeoDistanceSort struct {
+	Field          string
+	Points         []GeoPoint
+	Order          SortOrder
+	Unit           DistanceUnit
+	DistanceType   string // arc or plane
+	Mode           string // min, max, median or avg
+	IgnoreUnmapped bool
+}
+
+func (g GeoDistanceSort) sortClause(dc DataSource) (any, error) {
+	if err := requireField("GeoDistanceSort", g.Field); err != nil {
+		return nil, err
+	}
+	if len(g.Points) == 0 {
+		return nil, &BuildError{"GeoDistanceSort", "Points", ErrEmptyValues}
+	}
+	if err := checkOrder("GeoDistanceSort", g.Order, false); err != nil {
+		return nil, err
+	}
+	if g.Unit != "" && !slices.Contains(distanceUnits, g.Unit) {
+		return nil, &BuildError{"GeoDistanceSort", "Unit", fmt.Errorf("%w: unknown unit %q", ErrInvalidValue, g.Unit)}
+	}
+
+	points := make([]any, len(g.Points))
+	for i, p := range g.Points {
+		point, err := p.generate("GeoDistanceSort", fmt.Sprintf("Points[%d]", i))
+		if err != nil {
+			return nil, err
+		}
+		points[i] = point
+	}
+
+	body := map[string]any{g.Field: points}
+	setIf(body, "order", g.Order, g.Order != "")
+	setIf(body, "unit", g.Unit, g.Unit != "")
+	setIf(body, "distance_type", g.DistanceType, g.DistanceType != "")
+	setIf(body, "mode", g.Mode, g.Mode != "")
+	setIf(body, "ignore_unmapped", true, g.IgnoreUnmapped)
+	return map[string]any{"_geo_distance": body}, nil
+}
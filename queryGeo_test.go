@@ -0,0 +1,163 @@
+package queryBuilder_test
+
+import (
+	"testing"
+
+	"github.com/linksports/queryBuilder"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryGeo(t *testing.T) {
+	t.Run("geo_distance in filter", func(t *testing.T) {
+		query, err := queryBuilder.New().Query(
+			queryBuilder.Bool().Must(
+				queryBuilder.Match("name", "futsal"),
+			).Filter(
+				queryBuilder.GeoDistance("location", queryBuilder.LatLon(35.68, 139.69), queryBuilder.Distance{Value: 2.5, Unit: queryBuilder.Kilometers}),
+			),
+		).Build(queryBuilder.ES)
+
+		assert.NoError(t, err)
+		assert.Equal(t, queryBuilder.Trim(`{
+			"query":{
+				"bool":{
+					"must":[
+						{"match":{"name":"futsal"}}
+					],
+					"filter":[
+						{"geo_distance":{"distance":"2.5km","location":{"lat":35.68,"lon":139.69}}}
+					]
+				}
+			}
+		}`), query)
+	})
+
+	t.Run("points", func(t *testing.T) {
+		for _, c := range []struct {
+			point    queryBuilder.GeoPoint
+			expected string
+		}{
+			{queryBuilder.Geohash("xn76urx"), `"xn76urx"`},
+			{queryBuilder.WKTPoint("POINT (139.69 35.68)"), `"POINT (139.69 35.68)"`},
+		} {
+			query, err := queryBuilder.New().Query(
+				queryBuilder.GeoDistance("location", c.point, queryBuilder.Distance{Value: 500, Unit: queryBuilder.Meters}),
+			).Build(queryBuilder.ES)
+
+			assert.NoError(t, err)
+			assert.Equal(t, `{"query":{"geo_distance":{"distance":"500m","location":`+c.expected+`}}}`, query)
+		}
+
+		for _, q := range []queryBuilder.Query{
+			queryBuilder.GeoDistance("location", queryBuilder.LatLon(91, 0), queryBuilder.Distance{Value: 1, Unit: queryBuilder.Miles}),
+			queryBuilder.GeoDistance("location", queryBuilder.LatLon(0, 0), queryBuilder.Distance{Value: 1, Unit: "parsec"}),
+			queryBuilder.GeoDistance("location", queryBuilder.LatLon(0, 0), queryBuilder.Distance{Unit: queryBuilder.Miles}),
+			queryBuilder.GeoBoundingBox("location", queryBuilder.LatLon(35, 139), queryBuilder.LatLon(36, 140)),
+		} {
+			_, err := queryBuilder.New().Query(q).Build(queryBuilder.ES)
+			assert.ErrorIs(t, err, queryBuilder.ErrInvalidValue)
+		}
+	})
+
+	t.Run("geo_bounding_box in function_score", func(t *testing.T) {
+		query, err := queryBuilder.New().Query(
+			queryBuilder.FunctionScore(queryBuilder.MatchAll(), []queryBuilder.Function{
+				{
+					Filter: queryBuilder.GeoBoundingBox("location", queryBuilder.LatLon(36, 139), queryBuilder.LatLon(35, 140)),
+					Weight: 2,
+				},
+			}),
+		).Build(queryBuilder.ES)
+
+		assert.NoError(t, err)
+		assert.Equal(t, queryBuilder.Trim(`{
+			"query":{
+				"function_score":{
+					"query":{"match_all":{}},
+					"functions":[{
+						"filter":{"geo_bounding_box":{"location":{"bottom_right":{"lat":35,"lon":140},"top_left":{"lat":36,"lon":139}}}},
+						"weight":2
+					}]
+				}
+			}
+		}`), query)
+	})
+
+	t.Run("geo_polygon", func(t *testing.T) {
+		builder := queryBuilder.New().Query(
+			queryBuilder.GeoPolygon("location", []queryBuilder.GeoPoint{
+				queryBuilder.LatLon(35, 139),
+				queryBuilder.LatLon(36, 139),
+				queryBuilder.Geohash("xn77"),
+			}),
+		)
+
+		query, warnings, err := builder.BuildWithWarnings(queryBuilder.ES6)
+		assert.NoError(t, err)
+		assert.Equal(t, `{"query":{"geo_polygon":{"location":{"points":[{"lat":35,"lon":139},{"lat":36,"lon":139},"xn77"]}}}}`, query)
+		assert.Empty(t, warnings)
+
+		_, warnings, err = builder.BuildWithWarnings(queryBuilder.ES8)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"query.geo_polygon.location: geo_polygon is deprecated since Elasticsearch 7.12, use geo_shape"}, warnings)
+
+		_, err = queryBuilder.New().Query(
+			queryBuilder.GeoPolygon("location", []queryBuilder.GeoPoint{queryBuilder.LatLon(35, 139)}),
+		).Build(queryBuilder.ES)
+		assert.ErrorIs(t, err, queryBuilder.ErrEmptyValues)
+	})
+
+	t.Run("geo_shape", func(t *testing.T) {
+		query, err := queryBuilder.New().Query(
+			queryBuilder.GeoShape("area", map[string]any{
+				"type":        "envelope",
+				"coordinates": [][]float64{{139, 36}, {140, 35}},
+			}, "within"),
+		).Build(queryBuilder.ES)
+
+		assert.NoError(t, err)
+		assert.Equal(t, `{"query":{"geo_shape":{"area":{"relation":"within","shape":{"coordinates":[[139,36],[140,35]],"type":"envelope"}}}}}`, query)
+
+		query, err = queryBuilder.New().Query(&queryBuilder.GeoShapeQuery{
+			Field:        "area",
+			IndexedShape: &queryBuilder.IndexedShape{Index: "shapes", ID: "tokyo", Path: "area"},
+		}).Build(queryBuilder.ES)
+
+		assert.NoError(t, err)
+		assert.Equal(t, `{"query":{"geo_shape":{"area":{"indexed_shape":{"index":"shapes","id":"tokyo","path":"area"}}}}}`, query)
+
+		_, err = queryBuilder.New().Query(
+			queryBuilder.GeoShape("area", "POINT (139 35)", "overlaps"),
+		).Build(queryBuilder.ES)
+		assert.ErrorIs(t, err, queryBuilder.ErrInvalidValue)
+	})
+}
+
+func TestGeoDistanceSort(t *testing.T) {
+	query, err := queryBuilder.New().Query(queryBuilder.MatchAll()).SortBy(
+		queryBuilder.GeoDistanceSort{
+			Field:        "location",
+			Points:       []queryBuilder.GeoPoint{queryBuilder.LatLon(35.68, 139.69), queryBuilder.Geohash("xn76u")},
+			Order:        "asc",
+			Unit:         queryBuilder.Kilometers,
+			DistanceType: "plane",
+			Mode:         "min",
+		},
+		queryBuilder.Sort{"name", "asc"},
+	).Build(queryBuilder.ES)
+
+	assert.NoError(t, err)
+	assert.Equal(t, queryBuilder.Trim(`{
+		"query":{"match_all":{}},
+		"sort":[
+			{"_geo_distance":{"distance_type":"plane","location":[{"lat":35.68,"lon":139.69},"xn76u"],"mode":"min","order":"asc","unit":"km"}},
+			{"name":{"order":"asc"}}
+		]
+	}`), query)
+
+	_, err = queryBuilder.New().SortBy(
+		queryBuilder.GeoDistanceSort{Field: "location", Points: []queryBuilder.GeoPoint{queryBuilder.LatLon(0, 0)}, Order: "nearest"},
+	).Build(queryBuilder.ES)
+	assert.ErrorIs(t, err, queryBuilder.ErrInvalidValue)
+	assert.ErrorContains(t, err, "Builder.Sort(sort[0]): GeoDistanceSort(Order)")
+}
@@ -0,0 +1,203 @@
+package queryBuilder
+
+var openSearchOnly = []DataSource{OpenSearch}
+
+type KnnQuery struct {
+	Field  string
+	Params KnnParams
+}
+
+type KnnParams struct {
+	Vector      []float32
+	K           int
+	MinScore    float32
+	MaxDistance float32
+	Filter      Query
+}
+
+func (k *KnnQuery) queryName() string         { return "knn" }
+func (k *KnnQuery) dataSources() []DataSource { return openSearchOnly }
+
+func (k *KnnQuery) MarshalQuery(dc DataSource) (any, error) {
+	if err := checkDataSource(k, dc); err != nil {
+		return nil, err
+	}
+	if err := requireField("Knn", k.Field); err != nil {
+		return nil, err
+	}
+
+	var filter any
+	if k.Params.Filter != nil {
+		f, err := render(k.Params.Filter, dc, "Knn", "params.Filter")
+		if err != nil {
+			return nil, err
+		}
+		filter = f
+	}
+
+	return struct {
+		Knn map[string]any `json:"knn"`
+	}{
+		map[string]any{
+			k.Field: struct {
+				Vector      []float32 `json:"vector"`
+				K           int       `json:"k,omitempty"`
+				MinScore    float32   `json:"min_score,omitempty"`
+				MaxDistance float32   `json:"max_distance,omitempty"`
+				Filter      any       `json:"filter,omitempty"`
+			}{
+				k.Params.Vector,
+				k.Params.K,
+				k.Params.MinScore,
+				k.Params.MaxDistance,
+				filter,
+			},
+		},
+	}, nil
+}
+
+// Knn searches a knn_vector field. OpenSearch only.
+func Knn(field string, params KnnParams) Query {
+	return &KnnQuery{Field: field, Params: params}
+}
+
+type NeuralQuery struct {
+	Field  string
+	Params NeuralParams
+}
+
+type NeuralParams struct {
+	QueryText  string
+	QueryImage string // base64 encoded
+	ModelID    string
+	K          int
+	Filter     Query
+}
+
+func (n *NeuralQuery) queryName() string         { return "neural" }
+func (n *NeuralQuery) dataSources() []DataSource { return openSearchOnly }
+
+func (n *NeuralQuery) MarshalQuery(dc DataSource) (any, error) {
+	if err := checkDataSource(n, dc); err != nil {
+		return nil, err
+	}
+	if err := requireField("Neural", n.Field); err != nil {
+		return nil, err
+	}
+
+	var filter any
+	if n.Params.Filter != nil {
+		f, err := render(n.Params.Filter, dc, "Neural", "params.Filter")
+		if err != nil {
+			return nil, err
+		}
+		filter = f
+	}
+
+	return struct {
+		Neural map[string]any `json:"neural"`
+	}{
+		map[string]any{
+			n.Field: struct {
+				QueryText  string `json:"query_text,omitempty"`
+				QueryImage string `json:"query_image,omitempty"`
+				ModelID    string `json:"model_id,omitempty"`
+				K          int    `json:"k,omitempty"`
+				Filter     any    `json:"filter,omitempty"`
+			}{
+				n.Params.QueryText,
+				n.Params.QueryImage,
+				n.Params.ModelID,
+				n.Params.K,
+				filter,
+			},
+		},
+	}, nil
+}
+
+// Neural searches a field embedded by an ML model. OpenSearch only.
+func Neural(field string, params NeuralParams) Query {
+	return &NeuralQuery{Field: field, Params: params}
+}
+
+type HybridQuery struct {
+	Queries []Node
+}
+
+func (h *HybridQuery) queryName() string         { return "hybrid" }
+func (h *HybridQuery) dataSources() []DataSource { return openSearchOnly }
+
+func (h *HybridQuery) MarshalQuery(dc DataSource) (any, error) {
+	if err := checkDataSource(h, dc); err != nil {
+		return nil, err
+	}
+
+	if len(h.Queries) == 0 {
+		return nil, &BuildError{"Hybrid", "queries", ErrEmptyValues}
+	}
+	queries, err := renderAll(h.Queries, dc, "Hybrid", "queries")
+	if err != nil {
+		return nil, err
+	}
+
+	return struct {
+		Hybrid any `json:"hybrid"`
+	}{
+		struct {
+			Queries []any `json:"queries"`
+		}{
+			queries,
+		},
+	}, nil
+}
+
+// Hybrid combines the scores of the given queries. OpenSearch only.
+// Scores are normalized by the pipeline set with Builder.Normalization.
+func Hybrid(queries ...Query) Query {
+	return &HybridQuery{queries}
+}
+
+type NormalizationParams struct {
+	Normalization string // min_max or l2
+	Combination   string // arithmetic_mean, geometric_mean or harmonic_mean
+	Weights       []float32
+}
+
+func (n *NormalizationParams) generate() any {
+	type technique struct {
+		Technique  string `json:"technique,omitempty"`
+		Parameters any    `json:"parameters,omitempty"`
+	}
+
+	var parameters any
+	if len(n.Weights) > 0 {
+		parameters = struct {
+			Weights []float32 `json:"weights"`
+		}{n.Weights}
+	}
+
+	return struct {
+		PhaseResultsProcessors []any `json:"phase_results_processors"`
+	}{
+		[]any{
+			struct {
+				NormalizationProcessor any `json:"normalization-processor"`
+			}{
+				struct {
+					Normalization technique `json:"normalization"`
+					Combination   technique `json:"combination"`
+				}{
+					technique{Technique: n.Normalization},
+					technique{Technique: n.Combination, Parameters: parameters},
+				},
+			},
+		},
+	}
+}
+
+// Normalization attaches a temporary search pipeline with a normalization-processor,
+// which hybrid queries need to combine scores. OpenSearch only.
+func (b *Builder) Normalization(params NormalizationParams) *Builder {
+	b.pipeline = &params
+	return b
+}
@@ -0,0 +1,143 @@
+package queryBuilder_test
+
+import (
+	"testing"
+
+	"github.com/linksports/queryBuilder"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryOpenSearch(t *testing.T) {
+	t.Run("common query", func(t *testing.T) {
+		builder := queryBuilder.New()
+		query, err := builder.Query(
+			queryBuilder.Bool().Must(
+				queryBuilder.Term("target.keyword", "v"),
+			),
+		).Size(10).Build(queryBuilder.OpenSearch)
+
+		assert.NoError(t, err)
+		assert.Equal(t, queryBuilder.Trim(`{
+			"size":10,
+			"query":{
+				"bool":{
+					"must":[
+						{"term":{"target.keyword":"v"}}
+					]
+				}
+			}
+		}`), query)
+	})
+
+	t.Run("knn", func(t *testing.T) {
+		builder := queryBuilder.New()
+		query, err := builder.Query(
+			queryBuilder.Knn("embedding", queryBuilder.KnnParams{
+				Vector: []float32{0.5, 1, -2},
+				K:      3,
+				Filter: queryBuilder.Term("sport_id", 1),
+			}),
+		).Build(queryBuilder.OpenSearch)
+
+		assert.NoError(t, err)
+		assert.Equal(t, queryBuilder.Trim(`{
+			"query":{
+				"knn":{
+					"embedding":{
+						"vector":[0.5,1,-2],
+						"k":3,
+						"filter":{"term":{"sport_id":1}}
+					}
+				}
+			}
+		}`), query)
+	})
+
+	t.Run("neural", func(t *testing.T) {
+		builder := queryBuilder.New()
+		query, err := builder.Query(
+			queryBuilder.Neural("passage_embedding", queryBuilder.NeuralParams{
+				QueryText: "soccer club in tokyo",
+				ModelID:   "aVeif4oB5Vm0Tdw8zYO2",
+				K:         5,
+			}),
+		).Build(queryBuilder.OpenSearch)
+
+		assert.NoError(t, err)
+		assert.Equal(t, queryBuilder.Trim(`{
+			"query":{
+				"neural":{
+					"passage_embedding":{
+						"query_text":"soccer club in tokyo",
+						"model_id":"aVeif4oB5Vm0Tdw8zYO2",
+						"k":5
+					}
+				}
+			}
+		}`), query)
+	})
+
+	t.Run("hybrid+normalization", func(t *testing.T) {
+		builder := queryBuilder.New()
+		query, err := builder.Query(
+			queryBuilder.Hybrid(
+				queryBuilder.Match("name", "tokyo"),
+				queryBuilder.Neural("name_embedding", queryBuilder.NeuralParams{
+					QueryText: "tokyo",
+					ModelID:   "model",
+					K:         10,
+				}),
+			),
+		).Normalization(queryBuilder.NormalizationParams{
+			Normalization: "min_max",
+			Combination:   "arithmetic_mean",
+			Weights:       []float32{0.3, 0.7},
+		}).Build(queryBuilder.OpenSearch)
+
+		assert.NoError(t, err)
+		assert.Equal(t, queryBuilder.Trim(`{
+			"query":{
+				"hybrid":{
+					"queries":[
+						{"match":{"name":"tokyo"}},
+						{"neural":{"name_embedding":{"query_text":"tokyo","model_id":"model","k":10}}}
+					]
+				}
+			},
+			"search_pipeline":{
+				"phase_results_processors":[{
+					"normalization-processor":{
+						"normalization":{"technique":"min_max"},
+						"combination":{
+							"technique":"arithmetic_mean",
+							"parameters":{"weights":[0.3,0.7]}
+						}
+					}
+				}]
+			}
+		}`), query)
+	})
+
+	t.Run("OpenSearch only constructs on ES", func(t *testing.T) {
+		for name, builder := range map[string]*queryBuilder.Builder{
+			"knn": queryBuilder.New().Query(
+				queryBuilder.Knn("embedding", queryBuilder.KnnParams{Vector: []float32{1}, K: 1}),
+			),
+			"nested neural": queryBuilder.New().Query(
+				queryBuilder.Bool().Should(
+					queryBuilder.Neural("embedding", queryBuilder.NeuralParams{QueryText: "v", ModelID: "m", K: 1}),
+				),
+			),
+			"search_pipeline": queryBuilder.New().Query(
+				queryBuilder.MatchAll(),
+			).Normalization(queryBuilder.NormalizationParams{Normalization: "l2"}),
+		} {
+			t.Run(name, func(t *testing.T) {
+				query, err := builder.Build(queryBuilder.ES)
+
+				assert.ErrorIs(t, err, queryBuilder.ErrUnsupportedQuery)
+				assert.Empty(t, query)
+			})
+		}
+	})
+}
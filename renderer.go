@@ -0,0 +1,180 @@
+package queryBuilder
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+)
+
+var (
+	ErrUnsupportedDataSource = errors.New("unsupported data source")
+	ErrUnsupportedQuery      = errors.New("unsupported query")
+)
+
+type Renderer interface {
+	Render(dc DataSource, b *Builder) (string, error)
+}
+
+// Request is a read-only view of a Builder, for renderers of other backends.
+// Slices are copies; query and aggregation nodes are shared.
+type Request struct {
+	Query          Query
+	PostFilter     Query
+	Size           int
+	From           int
+	Sort           []Sorter
+	SearchAfter    []any
+	Source         []string
+	SourceExcludes []string
+	DisableSource  bool
+	StoredFields   []string
+	DocvalueFields []DocvalueField
+	ScriptFields   []ScriptField
+	Aggs           []Aggregation
+	Highlight      *Highlight // nil when not set
+	Suggest        []Suggester
+	CollapseField  string // empty when not set
+	Collapse       CollapseParams
+	Rescore        []Rescore
+	Knn            []KnnSearchParams
+	Retriever      Retriever
+	SearchPipeline *NormalizationParams
+	TrackTotalHits any
+	Extra          map[string]json.RawMessage // sections Parse kept verbatim, by key
+}
+
+// Request returns what has been set on b. The facets added by Facets are
+// included in PostFilter and Aggs as Build renders them.
+func (b *Builder) Request() Request {
+	postFilter, facetAggs := b.facetFilters()
+	r := Request{
+		Query:          b.query,
+		PostFilter:     postFilter,
+		Size:           b.size,
+		From:           b.from,
+		Sort:           slices.Clone(b.sort),
+		SearchAfter:    slices.Clone(b.searchAfter),
+		Source:         slices.Clone(b.source),
+		SourceExcludes: slices.Clone(b.excludes),
+		DisableSource:  b.noSource,
+		StoredFields:   slices.Clone(b.stored),
+		DocvalueFields: slices.Clone(b.docvalues),
+		ScriptFields:   slices.Clone(b.scripts),
+		Aggs:           append(slices.Clone(b.aggs), facetAggs...),
+		Highlight:      b.highlight,
+		Suggest:        slices.Clone(b.suggest),
+		Rescore:        slices.Clone(b.rescore),
+		Knn:            slices.Clone(b.knn),
+		Retriever:      b.retriever,
+		SearchPipeline: b.pipeline,
+		TrackTotalHits: b.totalHits,
+		Extra:          maps.Clone(b.extra),
+	}
+	if b.collapse != nil {
+		r.CollapseField = b.collapse.field
+		r.Collapse = b.collapse.params
+	}
+	return r
+}
+
+type RendererFunc func(dc DataSource, b *Builder) (string, error)
+
+func (f RendererFunc) Render(dc DataSource, b *Builder) (string, error) {
+	return f(dc, b)
+}
+
+// WarningRenderer is implemented by renderers that report deprecated syntax
+// for the target version; Builder.BuildWithWarnings returns those warnings.
+type WarningRenderer interface {
+	Renderer
+	RenderWithWarnings(dc DataSource, b *Builder) (string, []string, error)
+}
+
+type elasticSearchRenderer struct{}
+
+func (elasticSearchRenderer) Render(dc DataSource, b *Builder) (string, error) {
+	query, _, err := b.buildElasticSearch(dc)
+	return query, err
+}
+
+func (elasticSearchRenderer) RenderWithWarnings(dc DataSource, b *Builder) (string, []string, error) {
+	return b.buildElasticSearch(dc)
+}
+
+var (
+	renderersMu sync.RWMutex
+	renderers   = map[DataSource]Renderer{
+		ES:         elasticSearchRenderer{},
+		OpenSearch: elasticSearchRenderer{},
+	}
+)
+
+// RegisterRenderer makes a renderer available under the given DataSource.
+// Like database/sql.Register, it panics if r is nil or dc is already registered.
+func RegisterRenderer(dc DataSource, r Renderer) {
+	renderersMu.Lock()
+	defer renderersMu.Unlock()
+
+	if r == nil {
+		panic("queryBuilder: RegisterRenderer renderer is nil")
+	}
+	if _, dup := renderers[dc]; dup {
+		panic("queryBuilder: RegisterRenderer called twice for " + string(dc))
+	}
+	renderers[dc] = r
+}
+
+func LookupRenderer(dc DataSource) (Renderer, bool) {
+	renderersMu.RLock()
+	defer renderersMu.RUnlock()
+
+	if r, ok := renderers[dc]; ok {
+		return r, true
+	}
+	r, ok := renderers[dc.Dialect()]
+	return r, ok
+}
+
+// restrictedQuery is implemented by query types that only some data sources understand.
+type restrictedQuery interface {
+	queryName() string
+	dataSources() []DataSource
+}
+
+func checkDataSource(q restrictedQuery, dc DataSource) error {
+	if !slices.Contains(q.dataSources(), dc.Dialect()) {
+		return fmt.Errorf("%w: %s is not supported by %s", ErrUnsupportedQuery, q.queryName(), dc)
+	}
+	return nil
+}
+
+// render marshals the argument arg of call, attributing any error to it.
+func render(g Query, dc DataSource, call, arg string) (any, error) {
+	if g == nil {
+		return nil, &BuildError{call, arg, fmt.Errorf("%w: nil query", ErrWrongNodeKind)}
+	}
+	v, err := g.MarshalQuery(dc)
+	if err != nil {
+		return nil, &BuildError{call, arg, err}
+	}
+	return v, nil
+}
+
+func renderAll(gs []Query, dc DataSource, call, arg string) ([]any, error) {
+	if len(gs) == 0 {
+		return nil, nil
+	}
+	var errs []error
+	list := make([]any, len(gs))
+	for i, g := range gs {
+		v, err := render(g, dc, call, fmt.Sprintf("%s[%d]", arg, i))
+		if err != nil {
+			errs = append(errs, err)
+		}
+		list[i] = v
+	}
+	return list, errors.Join(errs...)
+}
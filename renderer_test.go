@@ -0,0 +1,130 @@
+package queryBuilder_test
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/linksports/queryBuilder"
+	"github.com/stretchr/testify/assert"
+)
+
+var registered atomic.Int64
+
+// uniqueDataSource returns a DataSource not registered yet, as the registry
+// is global and outlives a test run under -count.
+func uniqueDataSource(name string) queryBuilder.DataSource {
+	return queryBuilder.DataSource(fmt.Sprintf("%s-%d", name, registered.Add(1)))
+}
+
+func TestRenderer(t *testing.T) {
+	t.Run("unknown data source", func(t *testing.T) {
+		builder := queryBuilder.New()
+		query, err := builder.Query(
+			queryBuilder.MatchAll(),
+		).Build(queryBuilder.DataSource("Elasticsaerch"))
+
+		assert.ErrorIs(t, err, queryBuilder.ErrUnsupportedDataSource)
+		assert.Empty(t, query)
+	})
+
+	t.Run("registered renderer", func(t *testing.T) {
+		inHouse := uniqueDataSource("InHouse")
+		queryBuilder.RegisterRenderer(inHouse, queryBuilder.RendererFunc(
+			func(dc queryBuilder.DataSource, b *queryBuilder.Builder) (string, error) {
+				es, _ := queryBuilder.LookupRenderer(queryBuilder.ES)
+				query, err := es.Render(dc, b)
+				if err != nil {
+					return "", err
+				}
+				return strings.ToUpper(query), nil
+			},
+		))
+
+		builder := queryBuilder.New()
+		query, err := builder.Query(
+			queryBuilder.Term("target.keyword", "v"),
+		).Build(inHouse)
+
+		assert.NoError(t, err)
+		assert.Equal(t, queryBuilder.Trim(`{
+			"QUERY":{
+				"TERM":{"TARGET.KEYWORD":"V"}
+			}
+		}`), query)
+
+		assert.Panics(t, func() {
+			queryBuilder.RegisterRenderer(inHouse, queryBuilder.RendererFunc(
+				func(dc queryBuilder.DataSource, b *queryBuilder.Builder) (string, error) {
+					return "", nil
+				},
+			))
+		})
+		assert.Panics(t, func() {
+			queryBuilder.RegisterRenderer("Nil", nil)
+		})
+	})
+
+	t.Run("standalone renderer", func(t *testing.T) {
+		sql := uniqueDataSource("SQL")
+		queryBuilder.RegisterRenderer(sql, queryBuilder.RendererFunc(
+			func(dc queryBuilder.DataSource, b *queryBuilder.Builder) (string, error) {
+				r := b.Request()
+				if r.Highlight != nil || len(r.Aggs) > 0 || len(r.Extra) > 0 {
+					return "", fmt.Errorf("%w: only query, post_filter, _source, sort, size and from", queryBuilder.ErrUnsupportedQuery)
+				}
+				var where []string
+				for _, q := range []queryBuilder.Query{r.Query, r.PostFilter} {
+					switch q := q.(type) {
+					case nil:
+					case *queryBuilder.TermQuery:
+						where = append(where, fmt.Sprintf("%s = '%v'", q.Field, q.Value))
+					default:
+						return "", fmt.Errorf("%w: %T", queryBuilder.ErrUnsupportedQuery, q)
+					}
+				}
+				columns := "*"
+				if len(r.Source) > 0 {
+					columns = strings.Join(r.Source, ", ")
+				}
+				query := "SELECT " + columns + " FROM teams"
+				if len(where) > 0 {
+					query += " WHERE " + strings.Join(where, " AND ")
+				}
+				for i, s := range r.Sort {
+					if s, ok := s.(queryBuilder.Sort); ok {
+						sep := ", "
+						if i == 0 {
+							sep = " ORDER BY "
+						}
+						query += sep + s.Field + " " + strings.ToUpper(string(s.Order))
+					}
+				}
+				return query + fmt.Sprintf(" LIMIT %d OFFSET %d", r.Size, r.From), nil
+			},
+		))
+
+		query, err := queryBuilder.New().Query(
+			queryBuilder.Term("league", "J1"),
+		).PostFilter(
+			queryBuilder.Term("city", "tokyo"),
+		).Source([]string{"name", "city"}).Sort(queryBuilder.Sort{"name", queryBuilder.Asc}).Size(10).From(20).Build(sql)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "SELECT name, city FROM teams WHERE league = 'J1' AND city = 'tokyo' ORDER BY name ASC LIMIT 10 OFFSET 20", query)
+
+		_, err = queryBuilder.New().Query(queryBuilder.MatchAll()).Build(sql)
+		assert.ErrorIs(t, err, queryBuilder.ErrUnsupportedQuery)
+
+		_, err = queryBuilder.New().Highlight(
+			queryBuilder.NewHighlight(queryBuilder.HighlightParams{}).Field("name", queryBuilder.HighlightParams{}),
+		).Build(sql)
+		assert.ErrorIs(t, err, queryBuilder.ErrUnsupportedQuery)
+
+		parsed, err := queryBuilder.Parse(queryBuilder.ES, []byte(`{"min_score":0.5}`))
+		assert.NoError(t, err)
+		_, err = parsed.Build(sql)
+		assert.ErrorIs(t, err, queryBuilder.ErrUnsupportedQuery)
+	})
+}
@@ -0,0 +1,64 @@
+package queryBuilder
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// Rescore re-scores the top WindowSize hits of each shard with Query.
+type Rescore struct {
+	WindowSize         int
+	Query              Query
+	QueryWeight        *float64
+	RescoreQueryWeight *float64
+	ScoreMode          string // total, multiply, avg, max or min
+}
+
+var rescoreModes = []string{"", "total", "multiply", "avg", "max", "min"}
+
+func (r Rescore) generate(dc DataSource, arg string) (any, error) {
+	if r.WindowSize < 0 {
+		return nil, &BuildError{"Builder.Rescore", arg + ".WindowSize", ErrNegative}
+	}
+	if !slices.Contains(rescoreModes, r.ScoreMode) {
+		return nil, &BuildError{"Builder.Rescore", arg + ".ScoreMode", fmt.Errorf("%w: %q", ErrInvalidValue, r.ScoreMode)}
+	}
+	q, err := render(r.Query, dc, "Builder.Rescore", arg+".Query")
+	if err != nil {
+		return nil, err
+	}
+
+	query := map[string]any{"rescore_query": q}
+	if r.QueryWeight != nil {
+		query["query_weight"] = *r.QueryWeight
+	}
+	if r.RescoreQueryWeight != nil {
+		query["rescore_query_weight"] = *r.RescoreQueryWeight
+	}
+	setIf(query, "score_mode", r.ScoreMode, r.ScoreMode != "")
+
+	body := map[string]any{"query": query}
+	setIf(body, "window_size", r.WindowSize, r.WindowSize != 0)
+	return body, nil
+}
+
+func (b *Builder) Rescore(rescores ...Rescore) *Builder {
+	b.rescore = append(b.rescore, rescores...)
+	return b
+}
+
+func (b *Builder) generateRescore(dc DataSource) ([]any, error) {
+	if len(b.rescore) == 0 {
+		return nil, nil
+	}
+
+	var errs []error
+	rescore := make([]any, len(b.rescore))
+	for i, r := range b.rescore {
+		body, err := r.generate(dc, fmt.Sprintf("rescores[%d]", i))
+		errs = append(errs, err)
+		rescore[i] = body
+	}
+	return rescore, errors.Join(errs...)
+}
@@ -0,0 +1,213 @@
+package queryBuilder
+
+import (
+	"fmt"
+	"slices"
+)
+
+type SortOrder string
+
+const (
+	Asc  SortOrder = "asc"
+	Desc SortOrder = "desc"
+)
+
+// Values for FieldSort.Missing besides a custom value.
+const (
+	MissingFirst = "_first"
+	MissingLast  = "_last"
+)
+
+func checkOrder(call string, o SortOrder, required bool) error {
+	if o == Asc || o == Desc || o == "" && !required {
+		return nil
+	}
+	return &BuildError{call, "Order", fmt.Errorf("%w: sort order %q", ErrInvalidValue, o)}
+}
+
+var (
+	sortModes    = []string{"", "min", "max", "avg", "sum", "median"}
+	numericTypes = []string{"", "double", "long", "date", "date_nanos"}
+)
+
+// FieldSort sorts on a field with the options Sort lacks.
+type FieldSort struct {
+	Field        string
+	Order        SortOrder
+	Missing      any    // MissingFirst, MissingLast or a value
+	Mode         string // min, max, avg, sum or median
+	UnmappedType string
+	NumericType  string // double, long, date or date_nanos
+	Format       string
+	Nested       *NestedSort
+}
+
+// NestedSort picks the nested objects a sort value is taken from.
+// Nested sorts deeper levels of the object tree.
+type NestedSort struct {
+	Path        string
+	Filter      Query
+	MaxChildren int
+	Nested      *NestedSort
+}
+
+func (n *NestedSort) generate(dc DataSource, call string) (map[string]any, error) {
+	if n.Path == "" {
+		return nil, &BuildError{call, "Nested.Path", ErrEmptyField}
+	}
+	body := map[string]any{"path": n.Path}
+	if n.Filter != nil {
+		filter, err := render(n.Filter, dc, call, "Nested.Filter")
+		if err != nil {
+			return nil, err
+		}
+		body["filter"] = filter
+	}
+	setIf(body, "max_children", n.MaxChildren, n.MaxChildren != 0)
+	if n.Nested != nil {
+		nested, err := n.Nested.generate(dc, call)
+		if err != nil {
+			return nil, err
+		}
+		body["nested"] = nested
+	}
+	return body, nil
+}
+
+// legacyNested renders n as nested_path and nested_filter, the only syntax
+// before Elasticsearch 6.1. It was removed in 7.0.
+func (n *NestedSort) legacyNested(dc DataSource, call string, body map[string]any) error {
+	if n.Nested != nil || n.MaxChildren != 0 {
+		return fmt.Errorf("%w: nested sort levels and max_children require Elasticsearch 6.1 or later, got %s", ErrUnsupportedQuery, dc)
+	}
+	if n.Path == "" {
+		return &BuildError{call, "Nested.Path", ErrEmptyField}
+	}
+	body["nested_path"] = n.Path
+	if n.Filter != nil {
+		filter, err := render(n.Filter, dc, call, "Nested.Filter")
+		if err != nil {
+			return err
+		}
+		body["nested_filter"] = filter
+	}
+	return nil
+}
+
+func sortNested(dc DataSource, call string, n *NestedSort, body map[string]any) error {
+	if n == nil {
+		return nil
+	}
+	if v, ok := dc.esVersion(); ok && !v.atLeast(6, 1) {
+		return n.legacyNested(dc, call, body)
+	}
+	nested, err := n.generate(dc, call)
+	if err != nil {
+		return err
+	}
+	body["nested"] = nested
+	return nil
+}
+
+func (f FieldSort) sortClause(dc DataSource) (any, error) {
+	if err := requireField("FieldSort", f.Field); err != nil {
+		return nil, err
+	}
+	if err := checkOrder("FieldSort", f.Order, false); err != nil {
+		return nil, err
+	}
+	if !slices.Contains(sortModes, f.Mode) {
+		return nil, &BuildError{"FieldSort", "Mode", fmt.Errorf("%w: %q", ErrInvalidValue, f.Mode)}
+	}
+	if !slices.Contains(numericTypes, f.NumericType) {
+		return nil, &BuildError{"FieldSort", "NumericType", fmt.Errorf("%w: %q", ErrInvalidValue, f.NumericType)}
+	}
+	if v, ok := dc.esVersion(); ok && f.NumericType != "" && !v.atLeast(7, 2) {
+		return nil, fmt.Errorf("%w: numeric_type requires Elasticsearch 7.2 or later, got %s", ErrUnsupportedQuery, dc)
+	}
+
+	body := map[string]any{}
+	setIf(body, "order", f.Order, f.Order != "")
+	setIf(body, "missing", f.Missing, f.Missing != nil)
+	setIf(body, "mode", f.Mode, f.Mode != "")
+	setIf(body, "unmapped_type", f.UnmappedType, f.UnmappedType != "")
+	setIf(body, "numeric_type", f.NumericType, f.NumericType != "")
+	setIf(body, "format", f.Format, f.Format != "")
+	if err := sortNested(dc, "FieldSort", f.Nested, body); err != nil {
+		return nil, err
+	}
+	return map[string]any{f.Field: body}, nil
+}
+
+// ScoreSort sorts on relevance, descending by default.
+type ScoreSort struct {
+	Order SortOrder
+}
+
+func (s ScoreSort) sortClause(dc DataSource) (any, error) {
+	if err := checkOrder("ScoreSort", s.Order, false); err != nil {
+		return nil, err
+	}
+	if s.Order == "" {
+		return "_score", nil
+	}
+	return map[string]any{"_score": map[string]any{"order": s.Order}}, nil
+}
+
+// DocSort sorts in index order, the cheapest order for scrolling.
+type DocSort struct {
+	Order SortOrder
+}
+
+func (s DocSort) sortClause(dc DataSource) (any, error) {
+	if err := checkOrder("DocSort", s.Order, false); err != nil {
+		return nil, err
+	}
+	if s.Order == "" {
+		return "_doc", nil
+	}
+	return map[string]any{"_doc": map[string]any{"order": s.Order}}, nil
+}
+
+// ScriptSort sorts on the value of a script.
+type ScriptSort struct {
+	Type   string // number or string
+	Script Script
+	Order  SortOrder
+	Mode   string
+	Nested *NestedSort
+}
+
+func (s ScriptSort) sortClause(dc DataSource) (any, error) {
+	if s.Type != "number" && s.Type != "string" {
+		return nil, &BuildError{"ScriptSort", "Type", fmt.Errorf("%w: %q", ErrInvalidValue, s.Type)}
+	}
+	if s.Script.Source == "" {
+		return nil, &BuildError{"ScriptSort", "Script", ErrEmptyValues}
+	}
+	if err := checkOrder("ScriptSort", s.Order, false); err != nil {
+		return nil, err
+	}
+	if !slices.Contains(sortModes, s.Mode) {
+		return nil, &BuildError{"ScriptSort", "Mode", fmt.Errorf("%w: %q", ErrInvalidValue, s.Mode)}
+	}
+
+	body := map[string]any{"type": s.Type, "script": s.Script}
+	setIf(body, "order", s.Order, s.Order != "")
+	setIf(body, "mode", s.Mode, s.Mode != "")
+	if err := sortNested(dc, "ScriptSort", s.Nested, body); err != nil {
+		return nil, err
+	}
+	return map[string]any{"_script": body}, nil
+}
+
+// sortField returns the field s sorts on, or "" for sorts not on a single field.
+func sortField(s Sorter) string {
+	switch s := s.(type) {
+	case Sort:
+		return s.Field
+	case FieldSort:
+		return s.Field
+	}
+	return ""
+}
@@ -0,0 +1,105 @@
+package queryBuilder_test
+
+import (
+	"testing"
+
+	"github.com/linksports/queryBuilder"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSort(t *testing.T) {
+	t.Run("field sort", func(t *testing.T) {
+		query, err := queryBuilder.New().SortBy(
+			queryBuilder.FieldSort{
+				Field:        "price",
+				Order:        queryBuilder.Asc,
+				Missing:      queryBuilder.MissingLast,
+				Mode:         "avg",
+				UnmappedType: "long",
+				NumericType:  "double",
+			},
+			queryBuilder.ScoreSort{},
+			queryBuilder.DocSort{Order: queryBuilder.Desc},
+		).Build(queryBuilder.ES)
+
+		assert.NoError(t, err)
+		assert.Equal(t, queryBuilder.Trim(`{
+			"sort":[
+				{"price":{"missing":"_last","mode":"avg","numeric_type":"double","order":"asc","unmapped_type":"long"}},
+				"_score",
+				{"_doc":{"order":"desc"}}
+			]
+		}`), query)
+	})
+
+	t.Run("Sort and SortBy", func(t *testing.T) {
+		sorts := []queryBuilder.Sort{{"league", queryBuilder.Asc}, {"name", queryBuilder.Asc}}
+		query, err := queryBuilder.New().Sort(sorts...).SortBy(queryBuilder.ScoreSort{}).Build(queryBuilder.ES)
+
+		assert.NoError(t, err)
+		assert.Equal(t, `{"sort":[{"league":{"order":"asc"}},{"name":{"order":"asc"}},"_score"]}`, query)
+	})
+
+	t.Run("nested", func(t *testing.T) {
+		builder := queryBuilder.New().SortBy(
+			queryBuilder.FieldSort{
+				Field: "offers.price",
+				Order: queryBuilder.Asc,
+				Mode:  "min",
+				Nested: &queryBuilder.NestedSort{
+					Path:   "offers",
+					Filter: queryBuilder.Term("offers.color", "blue"),
+				},
+			},
+		)
+
+		query, err := builder.Build(queryBuilder.ES)
+		assert.NoError(t, err)
+		assert.Equal(t, `{"sort":[{"offers.price":{"mode":"min","nested":{"filter":{"term":{"offers.color":"blue"}},"path":"offers"},"order":"asc"}}]}`, query)
+
+		query, err = builder.Build(queryBuilder.ESVersion("6.0"))
+		assert.NoError(t, err)
+		assert.Equal(t, `{"sort":[{"offers.price":{"mode":"min","nested_filter":{"term":{"offers.color":"blue"}},"nested_path":"offers","order":"asc"}}]}`, query)
+
+		_, err = queryBuilder.New().SortBy(
+			queryBuilder.FieldSort{
+				Field:  "offers.variants.price",
+				Nested: &queryBuilder.NestedSort{Path: "offers", Nested: &queryBuilder.NestedSort{Path: "offers.variants"}},
+			},
+		).Build(queryBuilder.ESVersion("6.0"))
+		assert.ErrorIs(t, err, queryBuilder.ErrUnsupportedQuery)
+	})
+
+	t.Run("script", func(t *testing.T) {
+		query, err := queryBuilder.New().SortBy(
+			queryBuilder.ScriptSort{
+				Type:   "number",
+				Script: queryBuilder.Script{Source: "doc['goals'].value * params.factor", Params: map[string]any{"factor": 1.1}},
+				Order:  queryBuilder.Desc,
+			},
+		).Build(queryBuilder.ES)
+
+		assert.NoError(t, err)
+		assert.Equal(t, `{"sort":[{"_script":{"order":"desc","script":{"source":"doc['goals'].value * params.factor","params":{"factor":1.1}},"type":"number"}}]}`, query)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		for _, s := range []queryBuilder.Sorter{
+			queryBuilder.Sort{"name", "ascending"},
+			queryBuilder.Sort{"name", ""},
+			queryBuilder.FieldSort{Field: "name", Order: "up"},
+			queryBuilder.FieldSort{Field: "price", Mode: "mean"},
+			queryBuilder.ScoreSort{Order: "DESC"},
+			queryBuilder.ScriptSort{Type: "date", Script: queryBuilder.Script{Source: "1"}},
+		} {
+			_, err := queryBuilder.New().SortBy(s).Build(queryBuilder.ES)
+			assert.ErrorIs(t, err, queryBuilder.ErrInvalidValue)
+		}
+
+		_, err := queryBuilder.New().SortBy(queryBuilder.FieldSort{Field: "at", NumericType: "date_nanos"}).Build(queryBuilder.ES6)
+		assert.ErrorIs(t, err, queryBuilder.ErrUnsupportedQuery)
+
+		_, err = queryBuilder.New().Sort(queryBuilder.Sort{Order: queryBuilder.Asc}).Build(queryBuilder.ES)
+		assert.ErrorIs(t, err, queryBuilder.ErrEmptyField)
+	})
+}
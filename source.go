@@ -0,0 +1,127 @@
+package queryBuilder
+
+import (
+	"errors"
+	"fmt"
+)
+
+type DocvalueField struct {
+	Field  string
+	Format string // e.g. "epoch_millis" or "yyyy-MM-dd"
+}
+
+type ScriptField struct {
+	Name          string
+	Script        Script
+	IgnoreFailure bool
+}
+
+// SourceIncludes adds fields, wildcards allowed, to return from _source.
+func (b *Builder) SourceIncludes(fields ...string) *Builder {
+	b.source = append(b.source, fields...)
+	return b
+}
+
+// SourceExcludes adds fields, wildcards allowed, to leave out of _source.
+func (b *Builder) SourceExcludes(fields ...string) *Builder {
+	b.excludes = append(b.excludes, fields...)
+	return b
+}
+
+// DisableSource renders "_source": false, so hits carry no source at all.
+func (b *Builder) DisableSource() *Builder {
+	b.noSource = true
+	return b
+}
+
+// StoredFields lists the stored fields to return; "_none_" disables them
+// together with _source.
+func (b *Builder) StoredFields(fields ...string) *Builder {
+	b.stored = append(b.stored, fields...)
+	return b
+}
+
+func (b *Builder) DocvalueFields(fields ...DocvalueField) *Builder {
+	b.docvalues = append(b.docvalues, fields...)
+	return b
+}
+
+func (b *Builder) ScriptFields(fields ...ScriptField) *Builder {
+	b.scripts = append(b.scripts, fields...)
+	return b
+}
+
+// generateSource renders _source as false, a list of includes, or an
+// object once excludes are set.
+func (b *Builder) generateSource() (any, error) {
+	if b.noSource {
+		if len(b.source) > 0 || len(b.excludes) > 0 {
+			return nil, &BuildError{"Builder.DisableSource", "", fmt.Errorf("%w: _source is disabled but has includes or excludes", ErrInvalidValue)}
+		}
+		return false, nil
+	}
+	if len(b.excludes) == 0 {
+		if len(b.source) == 0 {
+			return nil, nil
+		}
+		return b.source, nil
+	}
+
+	source := map[string][]string{"excludes": b.excludes}
+	if len(b.source) > 0 {
+		source["includes"] = b.source
+	}
+	return source, nil
+}
+
+func (b *Builder) generateDocvalueFields(dc DataSource) ([]any, error) {
+	if len(b.docvalues) == 0 {
+		return nil, nil
+	}
+
+	var errs []error
+	fields := make([]any, len(b.docvalues))
+	for i, f := range b.docvalues {
+		arg := fmt.Sprintf("fields[%d]", i)
+		switch {
+		case f.Field == "":
+			errs = append(errs, &BuildError{"Builder.DocvalueFields", arg, ErrEmptyField})
+		case f.Format == "":
+			fields[i] = f.Field
+		default:
+			if v, ok := dc.esVersion(); ok && !v.atLeast(6, 4) {
+				errs = append(errs, &BuildError{"Builder.DocvalueFields", arg, fmt.Errorf("%w: docvalue_fields formats require Elasticsearch 6.4 or later, got %s", ErrUnsupportedQuery, dc)})
+			}
+			fields[i] = map[string]string{"field": f.Field, "format": f.Format}
+		}
+	}
+	return fields, errors.Join(errs...)
+}
+
+func (b *Builder) generateScriptFields() (map[string]any, error) {
+	if len(b.scripts) == 0 {
+		return nil, nil
+	}
+
+	var errs []error
+	fields := make(map[string]any, len(b.scripts))
+	for i, f := range b.scripts {
+		arg := fmt.Sprintf("fields[%d]", i)
+		if f.Name == "" {
+			errs = append(errs, &BuildError{"Builder.ScriptFields", arg, ErrEmptyName})
+			continue
+		}
+		if _, dup := fields[f.Name]; dup {
+			errs = append(errs, &BuildError{"Builder.ScriptFields", arg, fmt.Errorf("%w: %q", ErrDuplicateName, f.Name)})
+			continue
+		}
+		if f.Script.Source == "" {
+			errs = append(errs, &BuildError{"Builder.ScriptFields", arg, fmt.Errorf("%w: empty script", ErrEmptyValues)})
+			continue
+		}
+		field := map[string]any{"script": f.Script}
+		setIf(field, "ignore_failure", true, f.IgnoreFailure)
+		fields[f.Name] = field
+	}
+	return fields, errors.Join(errs...)
+}
@@ -0,0 +1,68 @@
+package queryBuilder_test
+
+import (
+	"testing"
+
+	"github.com/linksports/queryBuilder"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSource(t *testing.T) {
+	t.Run("includes+excludes", func(t *testing.T) {
+		query, err := queryBuilder.New().SourceIncludes("name", "team.*").SourceExcludes("team.description").Build(queryBuilder.ES)
+		assert.NoError(t, err)
+		assert.Equal(t, `{"_source":{"excludes":["team.description"],"includes":["name","team.*"]}}`, query)
+
+		query, err = queryBuilder.New().SourceExcludes("body").Build(queryBuilder.ES)
+		assert.NoError(t, err)
+		assert.Equal(t, `{"_source":{"excludes":["body"]}}`, query)
+
+		query, err = queryBuilder.New().Source([]string{"name"}).SourceIncludes("city").Build(queryBuilder.ES)
+		assert.NoError(t, err)
+		assert.Equal(t, `{"_source":["name","city"]}`, query)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		query, err := queryBuilder.New().Query(queryBuilder.MatchAll()).DisableSource().Build(queryBuilder.ES)
+		assert.NoError(t, err)
+		assert.Equal(t, `{"query":{"match_all":{}},"_source":false}`, query)
+
+		_, err = queryBuilder.New().DisableSource().SourceIncludes("name").Build(queryBuilder.ES)
+		assert.ErrorIs(t, err, queryBuilder.ErrInvalidValue)
+	})
+
+	t.Run("stored+docvalue+script fields", func(t *testing.T) {
+		builder := queryBuilder.New().DisableSource().StoredFields("title").DocvalueFields(
+			queryBuilder.DocvalueField{Field: "team_id"},
+			queryBuilder.DocvalueField{Field: "played_at", Format: "epoch_millis"},
+		).ScriptFields(
+			queryBuilder.ScriptField{
+				Name:   "goal_rate",
+				Script: queryBuilder.Script{Source: "doc['goals'].value / params.games", Params: map[string]any{"games": 38}},
+			},
+		)
+
+		query, err := builder.Build(queryBuilder.ES)
+		assert.NoError(t, err)
+		assert.Equal(t, queryBuilder.Trim(`{
+			"_source":false,
+			"stored_fields":["title"],
+			"docvalue_fields":["team_id",{"field":"played_at","format":"epoch_millis"}],
+			"script_fields":{
+				"goal_rate":{"script":{"source":"doc['goals'].value / params.games","params":{"games":38}}}
+			}
+		}`), query)
+
+		_, err = builder.Build(queryBuilder.ESVersion("6.2"))
+		assert.ErrorIs(t, err, queryBuilder.ErrUnsupportedQuery)
+
+		_, err = queryBuilder.New().ScriptFields(
+			queryBuilder.ScriptField{Name: "a", Script: queryBuilder.Script{Source: "1"}},
+			queryBuilder.ScriptField{Name: "a", Script: queryBuilder.Script{Source: "2"}},
+			queryBuilder.ScriptField{Name: "b"},
+		).DocvalueFields(queryBuilder.DocvalueField{}).Build(queryBuilder.ES)
+		assert.ErrorIs(t, err, queryBuilder.ErrDuplicateName)
+		assert.ErrorIs(t, err, queryBuilder.ErrEmptyValues)
+		assert.ErrorIs(t, err, queryBuilder.ErrEmptyField)
+	})
+}
@@ -0,0 +1,315 @@
+package queryBuilder
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// Suggester is an entry of the suggest section.
+type Suggester interface {
+	SuggestName() string
+	suggestion(dc DataSource) (any, error)
+}
+
+var suggestModes = []string{"", "missing", "popular", "always"}
+
+func checkSuggestMode(call, mode string) error {
+	if slices.Contains(suggestModes, mode) {
+		return nil
+	}
+	return &BuildError{call, "SuggestMode", fmt.Errorf("%w: %q", ErrInvalidValue, mode)}
+}
+
+// TermSuggester suggests corrections for each term of Text.
+type TermSuggester struct {
+	Name           string
+	Text           string
+	Field          string
+	Size           int
+	SuggestMode    string // missing, popular or always
+	Sort           string // score or frequency
+	StringDistance string
+	MaxEdits       int
+	PrefixLength   int
+	MinWordLength  int
+}
+
+func (s *TermSuggester) SuggestName() string {
+	return s.Name
+}
+
+func (s *TermSuggester) suggestion(dc DataSource) (any, error) {
+	if err := requireField("TermSuggester", s.Field); err != nil {
+		return nil, err
+	}
+	if s.Text == "" {
+		return nil, &BuildError{"TermSuggester", "Text", ErrEmptyValues}
+	}
+	if err := checkSuggestMode("TermSuggester", s.SuggestMode); err != nil {
+		return nil, err
+	}
+	if s.Sort != "" && s.Sort != "score" && s.Sort != "frequency" {
+		return nil, &BuildError{"TermSuggester", "Sort", fmt.Errorf("%w: %q", ErrInvalidValue, s.Sort)}
+	}
+
+	term := map[string]any{"field": s.Field}
+	setIf(term, "size", s.Size, s.Size != 0)
+	setIf(term, "suggest_mode", s.SuggestMode, s.SuggestMode != "")
+	setIf(term, "sort", s.Sort, s.Sort != "")
+	setIf(term, "string_distance", s.StringDistance, s.StringDistance != "")
+	setIf(term, "max_edits", s.MaxEdits, s.MaxEdits != 0)
+	setIf(term, "prefix_length", s.PrefixLength, s.PrefixLength != 0)
+	setIf(term, "min_word_length", s.MinWordLength, s.MinWordLength != 0)
+	return map[string]any{"text": s.Text, "term": term}, nil
+}
+
+// DirectGenerator produces the candidate terms of a PhraseSuggester.
+type DirectGenerator struct {
+	Field         string
+	SuggestMode   string
+	Size          int
+	MinWordLength int
+	PrefixLength  int
+	MaxEdits      int
+	PreFilter     string // analyzer applied before generating
+	PostFilter    string // analyzer applied to the candidates
+}
+
+func (g DirectGenerator) generate(call, arg string) (map[string]any, error) {
+	if g.Field == "" {
+		return nil, &BuildError{call, arg + ".Field", ErrEmptyField}
+	}
+	if err := checkSuggestMode(call, g.SuggestMode); err != nil {
+		return nil, err
+	}
+	body := map[string]any{"field": g.Field}
+	setIf(body, "suggest_mode", g.SuggestMode, g.SuggestMode != "")
+	setIf(body, "size", g.Size, g.Size != 0)
+	setIf(body, "min_word_length", g.MinWordLength, g.MinWordLength != 0)
+	setIf(body, "prefix_length", g.PrefixLength, g.PrefixLength != 0)
+	setIf(body, "max_edits", g.MaxEdits, g.MaxEdits != 0)
+	setIf(body, "pre_filter", g.PreFilter, g.PreFilter != "")
+	setIf(body, "post_filter", g.PostFilter, g.PostFilter != "")
+	return body, nil
+}
+
+// Collate drops phrase suggestions that match no document. Query is a
+// template; "{{suggestion}}" in its values stands for the suggestion.
+type Collate struct {
+	Query  Query
+	Params map[string]any
+	Prune  bool
+}
+
+// PhraseSuggester suggests corrections for Text as a whole.
+type PhraseSuggester struct {
+	Name                    string
+	Text                    string
+	Field                   string
+	Size                    int
+	GramSize                int
+	Confidence              *float64
+	MaxErrors               float64
+	RealWordErrorLikelihood float64
+	PreTag                  string
+	PostTag                 string
+	DirectGenerators        []DirectGenerator
+	Collate                 *Collate
+}
+
+func (s *PhraseSuggester) SuggestName() string {
+	return s.Name
+}
+
+func (s *PhraseSuggester) suggestion(dc DataSource) (any, error) {
+	if err := requireField("PhraseSuggester", s.Field); err != nil {
+		return nil, err
+	}
+	if s.Text == "" {
+		return nil, &BuildError{"PhraseSuggester", "Text", ErrEmptyValues}
+	}
+	if (s.PreTag == "") != (s.PostTag == "") {
+		return nil, &BuildError{"PhraseSuggester", "PreTag", fmt.Errorf("%w: PreTag and PostTag go together", ErrInvalidValue)}
+	}
+
+	phrase := map[string]any{"field": s.Field}
+	setIf(phrase, "size", s.Size, s.Size != 0)
+	setIf(phrase, "gram_size", s.GramSize, s.GramSize != 0)
+	if s.Confidence != nil {
+		phrase["confidence"] = *s.Confidence
+	}
+	setIf(phrase, "max_errors", s.MaxErrors, s.MaxErrors != 0)
+	setIf(phrase, "real_word_error_likelihood", s.RealWordErrorLikelihood, s.RealWordErrorLikelihood != 0)
+	if s.PreTag != "" {
+		phrase["highlight"] = map[string]string{"pre_tag": s.PreTag, "post_tag": s.PostTag}
+	}
+	if len(s.DirectGenerators) > 0 {
+		generators := make([]any, len(s.DirectGenerators))
+		for i, g := range s.DirectGenerators {
+			generator, err := g.generate("PhraseSuggester", fmt.Sprintf("DirectGenerators[%d]", i))
+			if err != nil {
+				return nil, err
+			}
+			generators[i] = generator
+		}
+		phrase["direct_generator"] = generators
+	}
+	if s.Collate != nil {
+		query, err := render(s.Collate.Query, dc, "PhraseSuggester", "Collate.Query")
+		if err != nil {
+			return nil, err
+		}
+		collate := map[string]any{"query": map[string]any{"source": query}}
+		setIf(collate, "params", s.Collate.Params, len(s.Collate.Params) > 0)
+		setIf(collate, "prune", true, s.Collate.Prune)
+		phrase["collate"] = collate
+	}
+	return map[string]any{"text": s.Text, "phrase": phrase}, nil
+}
+
+// SuggestContext filters or boosts completions by a category, or by a
+// location for geo contexts.
+type SuggestContext struct {
+	Category   string
+	Location   *GeoPoint
+	Boost      float64
+	Prefix     bool  // category contexts only
+	Precision  any   // geo contexts only: a geohash length or a distance such as "1km"
+	Neighbours []any // geo contexts only
+}
+
+func (c SuggestContext) generate(call, arg string) (map[string]any, error) {
+	if (c.Category == "") == (c.Location == nil) {
+		return nil, &BuildError{call, arg, fmt.Errorf("%w: exactly one of Category and Location must be set", ErrInvalidValue)}
+	}
+
+	body := map[string]any{}
+	if c.Location != nil {
+		if c.Prefix {
+			return nil, &BuildError{call, arg + ".Prefix", fmt.Errorf("%w: prefix applies to category contexts", ErrInvalidValue)}
+		}
+		point, err := c.Location.generate(call, arg+".Location")
+		if err != nil {
+			return nil, err
+		}
+		body["context"] = point
+		setIf(body, "precision", c.Precision, c.Precision != nil)
+		setIf(body, "neighbours", c.Neighbours, len(c.Neighbours) > 0)
+	} else {
+		if c.Precision != nil || len(c.Neighbours) > 0 {
+			return nil, &BuildError{call, arg + ".Precision", fmt.Errorf("%w: precision applies to geo contexts", ErrInvalidValue)}
+		}
+		body["context"] = c.Category
+		setIf(body, "prefix", true, c.Prefix)
+	}
+	setIf(body, "boost", c.Boost, c.Boost != 0)
+	return body, nil
+}
+
+type Fuzzy struct {
+	Fuzziness    any // e.g. 1, 2 or "AUTO"
+	MinLength    int
+	PrefixLength int
+}
+
+// CompletionSuggester completes Prefix, or matches Regex, against a
+// completion field.
+type CompletionSuggester struct {
+	Name           string
+	Field          string
+	Prefix         string
+	Regex          string
+	Size           int
+	SkipDuplicates bool
+	Fuzzy          *Fuzzy
+	Contexts       map[string][]SuggestContext // by context name of the mapping
+}
+
+func (s *CompletionSuggester) SuggestName() string {
+	return s.Name
+}
+
+func (s *CompletionSuggester) suggestion(dc DataSource) (any, error) {
+	if err := requireField("CompletionSuggester", s.Field); err != nil {
+		return nil, err
+	}
+	if (s.Prefix == "") == (s.Regex == "") {
+		return nil, &BuildError{"CompletionSuggester", "Prefix", fmt.Errorf("%w: exactly one of Prefix and Regex must be set", ErrInvalidValue)}
+	}
+	if s.Fuzzy != nil && s.Regex != "" {
+		return nil, &BuildError{"CompletionSuggester", "Fuzzy", fmt.Errorf("%w: fuzzy applies to prefix completion", ErrInvalidValue)}
+	}
+	if v, ok := dc.esVersion(); ok && s.SkipDuplicates && !v.atLeast(6, 1) {
+		return nil, fmt.Errorf("%w: skip_duplicates requires Elasticsearch 6.1 or later, got %s", ErrUnsupportedQuery, dc)
+	}
+
+	completion := map[string]any{"field": s.Field}
+	setIf(completion, "size", s.Size, s.Size != 0)
+	setIf(completion, "skip_duplicates", true, s.SkipDuplicates)
+	if s.Fuzzy != nil {
+		fuzzy := map[string]any{}
+		setIf(fuzzy, "fuzziness", s.Fuzzy.Fuzziness, s.Fuzzy.Fuzziness != nil)
+		setIf(fuzzy, "min_length", s.Fuzzy.MinLength, s.Fuzzy.MinLength != 0)
+		setIf(fuzzy, "prefix_length", s.Fuzzy.PrefixLength, s.Fuzzy.PrefixLength != 0)
+		completion["fuzzy"] = fuzzy
+	}
+	if len(s.Contexts) > 0 {
+		contexts := make(map[string]any, len(s.Contexts))
+		for name, list := range s.Contexts {
+			values := make([]any, len(list))
+			for i, c := range list {
+				value, err := c.generate("CompletionSuggester", fmt.Sprintf("Contexts[%q][%d]", name, i))
+				if err != nil {
+					return nil, err
+				}
+				values[i] = value
+			}
+			contexts[name] = values
+		}
+		completion["contexts"] = contexts
+	}
+
+	body := map[string]any{"completion": completion}
+	setIf(body, "prefix", s.Prefix, s.Prefix != "")
+	setIf(body, "regex", s.Regex, s.Regex != "")
+	return body, nil
+}
+
+// Suggest adds suggesters to the request, on their own or next to a query.
+func (b *Builder) Suggest(suggesters ...Suggester) *Builder {
+	b.suggest = append(b.suggest, suggesters...)
+	return b
+}
+
+func (b *Builder) generateSuggest(dc DataSource) (map[string]any, error) {
+	if len(b.suggest) == 0 {
+		return nil, nil
+	}
+
+	var errs []error
+	suggest := make(map[string]any, len(b.suggest))
+	for i, s := range b.suggest {
+		arg := fmt.Sprintf("suggesters[%d]", i)
+		switch {
+		case s == nil:
+			errs = append(errs, &BuildError{"Builder.Suggest", arg, fmt.Errorf("%w: nil suggester", ErrWrongNodeKind)})
+			continue
+		case s.SuggestName() == "":
+			errs = append(errs, &BuildError{"Builder.Suggest", arg, ErrEmptyName})
+			continue
+		}
+		name := s.SuggestName()
+		if _, dup := suggest[name]; dup {
+			errs = append(errs, &BuildError{"Builder.Suggest", arg, fmt.Errorf("%w: %q", ErrDuplicateName, name)})
+			continue
+		}
+		body, err := s.suggestion(dc)
+		if err != nil {
+			errs = append(errs, &BuildError{"Builder.Suggest", arg, err})
+			continue
+		}
+		suggest[name] = body
+	}
+	return suggest, errors.Join(errs...)
+}
@@ -0,0 +1,122 @@
+package queryBuilder_test
+
+import (
+	"testing"
+
+	"github.com/linksports/queryBuilder"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSuggest(t *testing.T) {
+	t.Run("term+phrase next to a query", func(t *testing.T) {
+		query, err := queryBuilder.New().Query(
+			queryBuilder.Match("name", "tokio futsall"),
+		).Suggest(
+			&queryBuilder.TermSuggester{
+				Name:        "spelling",
+				Text:        "tokio futsall",
+				Field:       "name",
+				SuggestMode: "popular",
+			},
+			&queryBuilder.PhraseSuggester{
+				Name:     "did_you_mean",
+				Text:     "tokio futsall",
+				Field:    "name.trigram",
+				GramSize: 3,
+				PreTag:   "<em>",
+				PostTag:  "</em>",
+				DirectGenerators: []queryBuilder.DirectGenerator{
+					{Field: "name.trigram", SuggestMode: "always"},
+					{Field: "name.reverse", PreFilter: "reverse", PostFilter: "reverse"},
+				},
+				Collate: &queryBuilder.Collate{
+					Query: queryBuilder.MatchPhrase("name", []string{"{{suggestion}}"}),
+					Prune: true,
+				},
+			},
+		).Build(queryBuilder.ES)
+
+		assert.NoError(t, err)
+		assert.Equal(t, queryBuilder.Trim(`{
+			"query":{"match":{"name":"tokio futsall"}},
+			"suggest":{
+				"did_you_mean":{
+					"phrase":{
+						"collate":{"prune":true,"query":{"source":{"match_phrase":{"name":"{{suggestion}}"}}}},
+						"direct_generator":[
+							{"field":"name.trigram","suggest_mode":"always"},
+							{"field":"name.reverse","post_filter":"reverse","pre_filter":"reverse"}
+						],
+						"field":"name.trigram",
+						"gram_size":3,
+						"highlight":{"post_tag":"</em>","pre_tag":"<em>"}
+					},
+					"text":"tokio futsall"
+				},
+				"spelling":{
+					"term":{"field":"name","suggest_mode":"popular"},
+					"text":"tokio futsall"
+				}
+			}
+		}`), query)
+	})
+
+	t.Run("completion with contexts", func(t *testing.T) {
+		tokyo := queryBuilder.LatLon(35.68, 139.69)
+		query, err := queryBuilder.New().Suggest(
+			&queryBuilder.CompletionSuggester{
+				Name:           "venues",
+				Field:          "suggest",
+				Prefix:         "shib",
+				Size:           5,
+				SkipDuplicates: true,
+				Fuzzy:          &queryBuilder.Fuzzy{Fuzziness: "AUTO"},
+				Contexts: map[string][]queryBuilder.SuggestContext{
+					"sport":    {{Category: "futsal", Boost: 2}, {Category: "foot", Prefix: true}},
+					"location": {{Location: &tokyo, Precision: "5km"}},
+				},
+			},
+		).Build(queryBuilder.ES)
+
+		assert.NoError(t, err)
+		assert.Equal(t, queryBuilder.Trim(`{
+			"suggest":{
+				"venues":{
+					"completion":{
+						"contexts":{
+							"location":[{"context":{"lat":35.68,"lon":139.69},"precision":"5km"}],
+							"sport":[{"boost":2,"context":"futsal"},{"context":"foot","prefix":true}]
+						},
+						"field":"suggest",
+						"fuzzy":{"fuzziness":"AUTO"},
+						"size":5,
+						"skip_duplicates":true
+					},
+					"prefix":"shib"
+				}
+			}
+		}`), query)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		for _, s := range []queryBuilder.Suggester{
+			&queryBuilder.TermSuggester{Name: "a", Text: "x", Field: "f", SuggestMode: "sometimes"},
+			&queryBuilder.PhraseSuggester{Name: "a", Text: "x", Field: "f", PreTag: "<em>"},
+			&queryBuilder.CompletionSuggester{Name: "a", Field: "f"},
+			&queryBuilder.CompletionSuggester{Name: "a", Field: "f", Prefix: "x", Contexts: map[string][]queryBuilder.SuggestContext{"c": {{}}}},
+			&queryBuilder.CompletionSuggester{Name: "a", Field: "f", Prefix: "x", Contexts: map[string][]queryBuilder.SuggestContext{"c": {{Category: "x", Precision: 3}}}},
+		} {
+			_, err := queryBuilder.New().Suggest(s).Build(queryBuilder.ES)
+			assert.ErrorIs(t, err, queryBuilder.ErrInvalidValue)
+		}
+
+		_, err := queryBuilder.New().Suggest(
+			&queryBuilder.TermSuggester{Name: "a", Text: "x", Field: "f"},
+			&queryBuilder.TermSuggester{Name: "a", Text: "y", Field: "f"},
+			&queryBuilder.TermSuggester{Name: "b", Text: "x"},
+		).Build(queryBuilder.ES)
+		assert.ErrorIs(t, err, queryBuilder.ErrDuplicateName)
+		assert.ErrorIs(t, err, queryBuilder.ErrEmptyField)
+		assert.ErrorContains(t, err, "Builder.Suggest(suggesters[2]): TermSuggester(field)")
+	})
+}